@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// AchievementHistory is one row of achievement_reference_history. IP,
+// UserAgent and RequestID are only returned to admins (see WithoutRequestMeta).
+type AchievementHistory struct {
+	ID               string    `json:"id"`
+	AchievementRefID string    `json:"achievement_ref_id"`
+	OldStatus        string    `json:"old_status"`
+	NewStatus        string    `json:"new_status"`
+	ChangedBy        *string   `json:"changed_by"`
+	ActorName        *string   `json:"actor_name"`
+	ActorRole        *string   `json:"actor_role"`
+	Note             *string   `json:"note"`
+	IP               *string   `json:"ip,omitempty"`
+	UserAgent        *string   `json:"user_agent,omitempty"`
+	RequestID        *string   `json:"request_id,omitempty"`
+	ChangedAt        time.Time `json:"changed_at"`
+}
+
+// WithoutRequestMeta drops the actor's IP, user agent and request ID.
+func (h AchievementHistory) WithoutRequestMeta() AchievementHistory {
+	h.IP, h.UserAgent, h.RequestID = nil, nil, nil
+	return h
+}
+
+// HistoryMeta is who/where a status change came from; written with the change.
+type HistoryMeta struct {
+	ActorID   string
+	ActorName string
+	ActorRole string
+	Note      string
+	IP        string
+	UserAgent string
+	RequestID string
+}
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
 
 // Postgres reference
 type AchievementReference struct {
@@ -8,23 +13,41 @@ type AchievementReference struct {
 	StudentID          string     `json:"student_id"`
 	MongoAchievementID string     `json:"mongo_achievement_id"`
 	Status             string     `json:"status"`
+	AchievementType    string     `json:"achievement_type"`
 	SubmittedAt        *time.Time `json:"submitted_at"`
 	VerifiedAt         *time.Time `json:"verified_at"`
 	VerifiedBy         *string    `json:"verified_by"`
 	RejectionNote      *string    `json:"rejection_note"`
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          *time.Time `json:"updated_at"`
+	Points             *int       `json:"points"`
+}
+
+// AchievementFilter narrows AchievementRepository.List; empty fields are ignored.
+type AchievementFilter struct {
+	StudentID string
+	AdvisorID string // lecturers.id, lewat students.advisor_id
+	Type      string
+	Statuses  []string
+	From      *time.Time // created_at >= From
+	To        *time.Time // created_at < To
 }
 
 // DTOs for requests/responses
 type CreateAchievementRequest struct {
-	StudentID string                 `json:"student_id" validate:"required"`
-	Doc map[string]interface{} `json:"doc"`
+	StudentID       string                 `json:"student_id" validate:"required"`
+	AchievementType string                 `json:"achievement_type" validate:"required"`
+	Title           string                 `json:"title" validate:"required"`
+	Description     string                 `json:"description"`
+	Doc             map[string]interface{} `json:"doc"` // field sesuai skema tipe, disimpan di Extra
 }
 
+// UpdateAchievementRequest is the PUT body: it replaces the editable content
+// of the Mongo document (missing fields are cleared).
 type UpdateAchievementRequest struct {
-	MongoAchievementID *string `json:"mongo_achievement_id,omitempty"`
-	
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Extra       map[string]interface{} `json:"extra"`
 }
 
 type SubmitRequest struct {
@@ -40,23 +63,120 @@ type AchievementResponse struct {
 	StudentID          string                 `json:"student_id"`
 	MongoAchievementID string                 `json:"mongo_achievement_id"`
 	Status             string                 `json:"status"`
-	Doc                map[string]interface{} `json:"doc,omitempty"` 
+	AchievementType    string                 `json:"achievement_type"`
+	Doc                map[string]interface{} `json:"doc,omitempty"`
 	SubmittedAt        *time.Time             `json:"submitted_at"`
 	VerifiedAt         *time.Time             `json:"verified_at"`
 	VerifiedBy         *string                `json:"verified_by"`
 	RejectionNote      *string                `json:"rejection_note"`
 	CreatedAt          time.Time              `json:"created_at"`
 	UpdatedAt          *time.Time             `json:"updated_at"`
+	Points             *int                   `json:"points"`
 }
 
 // Mongo document (what we store in Mongo)
 type MongoAchievement struct {
-	ID          interface{}            `bson:"_id,omitempty" json:"id"`
-	StudentID   string                 `bson:"student_id" json:"student_id"`
-	Title       string                 `bson:"title,omitempty" json:"title,omitempty"`
-	Description string                 `bson:"description,omitempty" json:"description,omitempty"`
-	Files       []map[string]interface{} `bson:"files,omitempty" json:"files,omitempty"` // attachments metadata
-	Extra       map[string]interface{} `bson:"extra,omitempty" json:"extra,omitempty"`
-	CreatedAt   time.Time              `bson:"created_at" json:"created_at"`
-	UpdatedAt   *time.Time             `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	ID        interface{} `bson:"_id,omitempty" json:"id"`
+	StudentID string      `bson:"student_id" json:"student_id"`
+	// AchievementType is a key of AchievementTypes; also stored on achievement_references
+	AchievementType string                 `bson:"achievement_type,omitempty" json:"achievement_type,omitempty"`
+	Title           string                 `bson:"title,omitempty" json:"title,omitempty"`
+	Description     string                 `bson:"description,omitempty" json:"description,omitempty"`
+	Files           []Attachment           `bson:"files,omitempty" json:"files,omitempty"`
+	Extra           map[string]interface{} `bson:"extra,omitempty" json:"extra,omitempty"`
+	Version         int                    `bson:"version,omitempty" json:"version,omitempty"` // naik setiap edit konten
+	CreatedAt       time.Time              `bson:"created_at" json:"created_at"`
+	UpdatedAt       *time.Time             `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// Attachment is one entry of MongoAchievement.Files; the content lives in the
+// storage backend under StorageKey.
+type Attachment struct {
+	FileID      string    `bson:"file_id,omitempty" json:"file_id"`
+	FileName    string    `bson:"file_name" json:"file_name"`
+	FileSize    int64     `bson:"file_size" json:"file_size"`
+	ContentType string    `bson:"content_type" json:"content_type"`
+	SHA256      string    `bson:"sha256,omitempty" json:"sha256,omitempty"`
+	Backend     string    `bson:"backend,omitempty" json:"backend,omitempty"`
+	StorageKey  string    `bson:"storage_key,omitempty" json:"-"`
+	UploadedBy  string    `bson:"uploaded_by,omitempty" json:"uploaded_by,omitempty"`
+	UploadedAt  time.Time `bson:"uploaded_at" json:"uploaded_at"`
+
+	// preview (thumbnail JPEG) dibuat worker di background
+	PreviewStatus string `bson:"preview_status,omitempty" json:"preview_status,omitempty"` // pending | ready | unavailable | failed
+	PreviewKey    string `bson:"preview_key,omitempty" json:"-"`
+	PreviewURL    string `bson:"-" json:"preview_url,omitempty"` // URL bertanda tangan, diisi saat response
+}
+
+// Status preview lampiran.
+const (
+	PreviewPending     = "pending"
+	PreviewReady       = "ready"
+	PreviewUnavailable = "unavailable" // tipe file tidak bisa dibuat preview
+	PreviewFailed      = "failed"
+)
+
+// PreviewJob identifies a file whose preview must be generated.
+type PreviewJob struct {
+	MongoID string
+	File    Attachment
+}
+
+// AchievementVersion is a snapshot of the editable content after an edit,
+// stored in the achievement_versions collection.
+type AchievementVersion struct {
+	ID            interface{}            `bson:"_id,omitempty" json:"id"`
+	AchievementID string                 `bson:"achievement_id" json:"achievement_id"` // hex _id of the achievements doc
+	Version       int                    `bson:"version" json:"version"`
+	Title         string                 `bson:"title" json:"title"`
+	Description   string                 `bson:"description" json:"description"`
+	Extra         map[string]interface{} `bson:"extra,omitempty" json:"extra,omitempty"`
+	EditedBy      string                 `bson:"edited_by,omitempty" json:"edited_by,omitempty"`
+	EditedAt      time.Time              `bson:"edited_at" json:"edited_at"`
+}
+
+// AchievementDocFields are the document fields that can be selected with
+// ?fields= on list endpoints; "extra.<key>" selects a single extra field.
+var AchievementDocFields = []string{"title", "description", "files", "extra", "version", "created_at", "updated_at"}
+
+var extraFieldPattern = regexp.MustCompile(`^extra\.[A-Za-z0-9_]+$`)
+
+// ParseDocFields parses ?fields=title,extra.level. It returns nil for "all
+// fields"; the special value "none" yields an empty, non-nil slice.
+func ParseDocFields(raw string) ([]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if raw == "none" {
+		return []string{}, nil
+	}
+	var out []string
+	wholeExtra := false
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		switch {
+		case f == "":
+			continue
+		case contains(AchievementDocFields, f):
+			wholeExtra = wholeExtra || f == "extra"
+		case extraFieldPattern.MatchString(f):
+		default:
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		if !contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	if wholeExtra {
+		// "extra" dan "extra.x" tidak boleh bersamaan di projection Mongo
+		kept := out[:0]
+		for _, f := range out {
+			if !strings.HasPrefix(f, "extra.") {
+				kept = append(kept, f)
+			}
+		}
+		out = kept
+	}
+	return out, nil
 }
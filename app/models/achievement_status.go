@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status prestasi di achievement_references.
+const (
+	StatusDraft     = "draft"
+	StatusSubmitted = "submitted"
+	StatusVerified  = "verified"
+	StatusRejected  = "rejected"
+	StatusArchived  = "archived"
+	StatusDeleted   = "deleted"
+)
+
+// AchievementTransitions is the status state machine: from -> allowed next statuses.
+//
+//	draft -> submitted -> verified -> archived
+//	              \-> rejected -> draft (revisi)
+//	draft/rejected -> deleted
+var AchievementTransitions = map[string][]string{
+	StatusDraft:     {StatusSubmitted, StatusDeleted},
+	StatusSubmitted: {StatusVerified, StatusRejected},
+	StatusRejected:  {StatusDraft, StatusDeleted},
+	StatusVerified:  {StatusArchived},
+	StatusArchived:  {},
+	StatusDeleted:   {},
+}
+
+// AllowedTransitions returns the statuses reachable from status.
+func AllowedTransitions(from string) []string {
+	next := AchievementTransitions[from]
+	if next == nil {
+		return []string{}
+	}
+	return next
+}
+
+func CanTransition(from, to string) bool {
+	for _, s := range AchievementTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEditableStatus reports whether the document content may still be edited.
+func IsEditableStatus(status string) bool {
+	return status == StatusDraft || status == StatusRejected
+}
+
+// TransitionError is returned when a status change is not allowed from the
+// current status.
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change status from %q to %q", e.From, e.To)
+}
+
+// StatusChange describes a transition plus the columns it sets; nil fields
+// keep their current value unless ResetReview is set.
+type StatusChange struct {
+	To            string
+	SubmittedAt   *time.Time
+	VerifiedAt    *time.Time
+	VerifiedBy    *string
+	RejectionNote *string
+	// ResetReview mengosongkan verified_at, verified_by dan rejection_note
+	// hasil review sebelumnya (revisi dan pengajuan ulang); catatan lama tetap
+	// ada di riwayat.
+	ResetReview bool
+	// poin dihitung saat verifikasi (lihat PointsService)
+	Points         *int
+	PointRuleSetID *string
+	// kode verifikasi publik yang diterbitkan bersama perubahan status
+	VerificationCode string
+	// Notify membangun notifikasi yang ditulis dalam transaksi yang sama;
+	// dipanggil hanya setelah state machine menerima perubahan, jadi 409
+	// tidak membaca penerima.
+	Notify func() []NotificationDraft
+}
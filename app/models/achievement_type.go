@@ -0,0 +1,204 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Jenis field pada skema tipe prestasi.
+const (
+	FieldString = "string"
+	FieldEnum   = "enum"
+	FieldDate   = "date" // YYYY-MM-DD
+	FieldURL    = "url"
+	FieldInt    = "int"
+)
+
+// Tingkat prestasi, dipakai bersama oleh semua tipe (dan oleh perhitungan poin).
+var AchievementLevels = []string{"international", "national", "regional", "local"}
+
+// TypeField describes one field of an achievement type's schema; the values
+// live in MongoAchievement.Extra under Name.
+type TypeField struct {
+	Name     string   `json:"name"`
+	Kind     string   `json:"kind"`
+	Required bool     `json:"required"`
+	Values   []string `json:"values,omitempty"` // untuk FieldEnum
+	Label    string   `json:"label"`
+}
+
+type AchievementType struct {
+	Name   string      `json:"name"`
+	Label  string      `json:"label"`
+	Fields []TypeField `json:"fields"`
+}
+
+// Tipe prestasi yang dikenali.
+const (
+	TypeCompetition      = "competition"
+	TypePublication      = "publication"
+	TypeCertification    = "certification"
+	TypeOrganisation     = "organisation"
+	TypeCommunityService = "community_service"
+)
+
+// AchievementTypes is the registry of achievement types and their schemas.
+var AchievementTypes = map[string]AchievementType{
+	TypeCompetition: {
+		Name: TypeCompetition, Label: "Kompetisi",
+		Fields: []TypeField{
+			{Name: "level", Kind: FieldEnum, Required: true, Values: AchievementLevels, Label: "Tingkat"},
+			{Name: "rank", Kind: FieldEnum, Required: true, Values: []string{"first", "second", "third", "honourable_mention", "finalist", "participant"}, Label: "Peringkat"},
+			{Name: "organiser", Kind: FieldString, Required: true, Label: "Penyelenggara"},
+			{Name: "event_name", Kind: FieldString, Required: true, Label: "Nama kompetisi"},
+			{Name: "event_date", Kind: FieldDate, Required: true, Label: "Tanggal"},
+			{Name: "team_size", Kind: FieldInt, Label: "Jumlah anggota tim"},
+			{Name: "evidence", Kind: FieldURL, Required: true, Label: "Bukti"},
+		},
+	},
+	TypePublication: {
+		Name: TypePublication, Label: "Publikasi",
+		Fields: []TypeField{
+			{Name: "level", Kind: FieldEnum, Required: true, Values: AchievementLevels, Label: "Tingkat"},
+			{Name: "publication_type", Kind: FieldEnum, Required: true, Values: []string{"journal", "conference", "book", "other"}, Label: "Jenis publikasi"},
+			{Name: "publisher", Kind: FieldString, Required: true, Label: "Penerbit / jurnal"},
+			{Name: "authors", Kind: FieldString, Required: true, Label: "Penulis"},
+			{Name: "event_date", Kind: FieldDate, Required: true, Label: "Tanggal terbit"},
+			{Name: "doi", Kind: FieldString, Label: "DOI"},
+			{Name: "evidence", Kind: FieldURL, Required: true, Label: "Bukti"},
+		},
+	},
+	TypeCertification: {
+		Name: TypeCertification, Label: "Sertifikasi",
+		Fields: []TypeField{
+			{Name: "level", Kind: FieldEnum, Required: true, Values: AchievementLevels, Label: "Tingkat"},
+			{Name: "organiser", Kind: FieldString, Required: true, Label: "Lembaga penerbit"},
+			{Name: "certificate_number", Kind: FieldString, Label: "Nomor sertifikat"},
+			{Name: "event_date", Kind: FieldDate, Required: true, Label: "Tanggal terbit"},
+			{Name: "expiry_date", Kind: FieldDate, Label: "Berlaku sampai"},
+			{Name: "evidence", Kind: FieldURL, Required: true, Label: "Bukti"},
+		},
+	},
+	TypeOrganisation: {
+		Name: TypeOrganisation, Label: "Organisasi",
+		Fields: []TypeField{
+			{Name: "level", Kind: FieldEnum, Required: true, Values: AchievementLevels, Label: "Tingkat"},
+			{Name: "organiser", Kind: FieldString, Required: true, Label: "Nama organisasi"},
+			{Name: "rank", Kind: FieldEnum, Required: true, Values: []string{"chair", "vice_chair", "secretary", "treasurer", "division_head", "member"}, Label: "Jabatan"},
+			{Name: "event_date", Kind: FieldDate, Required: true, Label: "Mulai menjabat"},
+			{Name: "end_date", Kind: FieldDate, Label: "Selesai menjabat"},
+			{Name: "evidence", Kind: FieldURL, Required: true, Label: "Bukti (SK)"},
+		},
+	},
+	TypeCommunityService: {
+		Name: TypeCommunityService, Label: "Pengabdian masyarakat",
+		Fields: []TypeField{
+			{Name: "level", Kind: FieldEnum, Required: true, Values: AchievementLevels, Label: "Tingkat"},
+			{Name: "organiser", Kind: FieldString, Required: true, Label: "Penyelenggara"},
+			{Name: "location", Kind: FieldString, Required: true, Label: "Lokasi"},
+			{Name: "event_date", Kind: FieldDate, Required: true, Label: "Tanggal"},
+			{Name: "duration_days", Kind: FieldInt, Label: "Durasi (hari)"},
+			{Name: "evidence", Kind: FieldURL, Required: true, Label: "Bukti"},
+		},
+	},
+}
+
+// AchievementTypeList returns the registry sorted by name (for the API).
+func AchievementTypeList() []AchievementType {
+	out := make([]AchievementType, 0, len(AchievementTypes))
+	for _, t := range AchievementTypes {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ValidationErrors maps a field name to what is wrong with it.
+type ValidationErrors map[string]string
+
+func (e ValidationErrors) Error() string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+e[k])
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// ValidateAchievement checks title and the type-specific fields in extra.
+// Keys in extra that are not part of the schema are allowed and left alone.
+func ValidateAchievement(typeName, title string, extra map[string]interface{}) error {
+	errs := ValidationErrors{}
+	if strings.TrimSpace(title) == "" {
+		errs["title"] = "required"
+	}
+	t, ok := AchievementTypes[typeName]
+	if !ok {
+		errs["achievement_type"] = fmt.Sprintf("unknown type %q", typeName)
+		return errs
+	}
+
+	for _, f := range t.Fields {
+		v, present := extra[f.Name]
+		if !present || v == nil || v == "" {
+			if f.Required {
+				errs["extra."+f.Name] = "required"
+			}
+			continue
+		}
+		if msg := validateField(f, v); msg != "" {
+			errs["extra."+f.Name] = msg
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateField(f TypeField, v interface{}) string {
+	switch f.Kind {
+	case FieldInt:
+		switch n := v.(type) {
+		case int, int32, int64:
+			return ""
+		case float64:
+			if n == float64(int64(n)) {
+				return ""
+			}
+		}
+		return "must be an integer"
+	}
+
+	str, ok := v.(string)
+	if !ok {
+		return "must be a string"
+	}
+	switch f.Kind {
+	case FieldEnum:
+		for _, allowed := range f.Values {
+			if str == allowed {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(f.Values, ", ")
+	case FieldDate:
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case FieldURL:
+		u, err := url.Parse(str)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an http(s) URL"
+		}
+	}
+	return ""
+}
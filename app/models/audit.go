@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AuditLog is one row of audit_logs; Details is free-form per action.
+type AuditLog struct {
+	ID         string                 `json:"id"`
+	ActorID    *string                `json:"actor_id"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   string                 `json:"entity_id"`
+	Details    map[string]interface{} `json:"details"`
+	IP         *string                `json:"ip"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditEntry is an audit row to write together with the change it describes.
+type AuditEntry struct {
+	ActorID    string
+	Action     string
+	EntityType string
+	EntityID   string
+	Details    map[string]interface{}
+	IP         string
+}
@@ -1,16 +1,41 @@
 package models
 
+import "time"
+
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
 type LoginResponse struct {
-	ID          string   `json:"id"`
-	Username    string   `json:"username"`
-	Email       string   `json:"email"`
-	FullName    string   `json:"full_name"`
-	RoleID      string   `json:"role_id"`
-	Token       string   `json:"token"`
-	Permissions []string `json:"permissions"`
+	ID           string   `json:"id"`
+	Username     string   `json:"username"`
+	Email        string   `json:"email"`
+	FullName     string   `json:"full_name"`
+	RoleID       string   `json:"role_id"`
+	Token        string   `json:"token"`
+	RefreshToken string   `json:"refresh_token"`
+	Permissions  []string `json:"permissions"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken is a stored (hashed) refresh token row.
+type RefreshToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	FamilyID   string     `json:"family_id"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	ReplacedBy *string    `json:"replaced_by"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// CredentialType is the "type" of AchievementCredential.
+const CredentialType = "EkrpAchievementCredential"
+
+type CredentialIssuer struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Kid  string `json:"kid"` // kunci yang menandatangani, lihat /.well-known/jwks.json
+}
+
+type CredentialSubject struct {
+	StudentID    string `json:"student_id"` // NIM
+	Name         string `json:"name"`
+	ProgramStudy string `json:"program_study"`
+}
+
+// AchievementCredential is the signed payload of an exported achievement.
+type AchievementCredential struct {
+	ID              string              `json:"id"`
+	Type            string              `json:"type"`
+	Issuer          CredentialIssuer    `json:"issuer"`
+	IssuedAt        time.Time           `json:"issued_at"`
+	Subject         CredentialSubject   `json:"subject"`
+	Achievement     AchievementResponse `json:"achievement"`
+	VerificationURL string              `json:"verification_url,omitempty"`
+}
+
+// CredentialVerification is the result of POST /api/v1/credentials/verify.
+type CredentialVerification struct {
+	Valid      bool                   `json:"valid"`
+	Error      string                 `json:"error,omitempty"`
+	Kid        string                 `json:"kid,omitempty"`
+	KeyRetired bool                   `json:"key_retired,omitempty"`
+	Credential *AchievementCredential `json:"credential,omitempty"`
+}
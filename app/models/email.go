@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Status baris email_outbox.
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailFailed  = "failed" // percobaan habis, bisa dikirim ulang manual
+)
+
+// EmailEvents are the events that also send an email.
+var EmailEvents = []string{
+	EventAchievementSubmitted,
+	EventAchievementVerified,
+	EventAchievementRejected,
+}
+
+func IsEmailEvent(event string) bool {
+	return contains(EmailEvents, event)
+}
+
+// Recipient is a user that may receive notifications.
+type Recipient struct {
+	UserID   string
+	Email    string
+	FullName string
+	Language string
+}
+
+// EmailDraft is a rendered email queued in the outbox together with the
+// change that caused it.
+type EmailDraft struct {
+	ToAddress string
+	ToName    string
+	Subject   string
+	TextBody  string
+	HTMLBody  string
+}
+
+// OutboxEmail is one row of email_outbox.
+type OutboxEmail struct {
+	ID            string     `json:"id"`
+	UserID        *string    `json:"user_id"`
+	Event         string     `json:"event"`
+	AchievementID *string    `json:"achievement_id"`
+	ToAddress     string     `json:"to_address"`
+	ToName        string     `json:"to_name"`
+	Subject       string     `json:"subject"`
+	TextBody      string     `json:"-"`
+	HTMLBody      string     `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     *string    `json:"last_error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+// OutboxFilter narrows EmailOutboxRepository.FindPage.
+type OutboxFilter struct {
+	Status string
+}
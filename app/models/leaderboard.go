@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// LeaderboardFilter narrows which verified achievements count; empty fields are ignored.
+type LeaderboardFilter struct {
+	ProgramStudy string
+	AcademicYear string // angkatan mahasiswa (students.academic_year)
+	Category     string // achievement_type
+	From         *time.Time
+	To           *time.Time // exclusive, berdasarkan verified_at
+}
+
+type LeaderboardEntry struct {
+	Rank           int     `json:"rank"`
+	Group          string  `json:"group,omitempty"`
+	StudentID      string  `json:"student_id"`
+	NIM            string  `json:"nim"`
+	FullName       string  `json:"full_name"`
+	ProgramStudy   string  `json:"program_study"`
+	AcademicYear   string  `json:"academic_year"`
+	TotalPoints    int     `json:"total_points"`
+	Achievements   int     `json:"achievements"`
+	TopAchievement *string `json:"top_achievement"` // judul prestasi dengan poin tertinggi
+	TopMongoID     string  `json:"-"`
+}
@@ -9,3 +9,8 @@ type Lecturer struct {
 	Department string    `json:"department"`
 	CreatedAt  time.Time `json:"created_at"`
 }
+
+// LecturerFilter narrows LecturerRepository.FindPage; empty fields are ignored.
+type LecturerFilter struct {
+	Department string
+}
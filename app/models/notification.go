@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event notifikasi dari alur prestasi.
+const (
+	EventAchievementSubmitted = "achievement.submitted"
+	EventAchievementVerified  = "achievement.verified"
+	EventAchievementRejected  = "achievement.rejected"
+	EventAchievementCommented = "achievement.commented"
+)
+
+// NotificationEvents lists every event a user can opt out of.
+var NotificationEvents = []string{
+	EventAchievementSubmitted,
+	EventAchievementVerified,
+	EventAchievementRejected,
+	EventAchievementCommented,
+}
+
+func IsNotificationEvent(event string) bool {
+	return contains(NotificationEvents, event)
+}
+
+type Notification struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	Event         string     `json:"event"`
+	AchievementID *string    `json:"achievement_id"`
+	ActorID       *string    `json:"actor_id"`
+	ActorName     *string    `json:"actor_name"`
+	Title         string     `json:"title"`
+	Body          string     `json:"body"`
+	ReadAt        *time.Time `json:"read_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// NotificationDraft is a notification to create together with the change
+// that caused it (dalam transaksi yang sama).
+type NotificationDraft struct {
+	UserID        string
+	Event         string
+	AchievementID string
+	ActorID       string
+	ActorName     string
+	Title         string
+	Body          string
+	// email ke penerima; nil bila event ini tidak dikirim lewat email
+	Email *EmailDraft
+}
+
+// NotificationFilter narrows NotificationRepository.FindPage.
+type NotificationFilter struct {
+	UserID string
+	Unread bool
+	Event  string
+}
+
+// NotificationPreference says whether a user receives an event in the app
+// and by email.
+type NotificationPreference struct {
+	Event string `json:"event"`
+	InApp bool   `json:"in_app"`
+	Email bool   `json:"email"`
+}
+
+// NotificationPreferenceUpdate changes one event; nil fields keep their value.
+type NotificationPreferenceUpdate struct {
+	Event string `json:"event"`
+	InApp *bool  `json:"in_app"`
+	Email *bool  `json:"email"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Language    *string                        `json:"language"` // bahasa email: id | en
+	Preferences []NotificationPreferenceUpdate `json:"preferences"`
+}
+
+// Validate checks the request; languages is the list of supported languages.
+func (r UpdateNotificationPreferencesRequest) Validate(languages []string) error {
+	errs := ValidationErrors{}
+	if r.Language != nil && !contains(languages, *r.Language) {
+		errs["language"] = fmt.Sprintf("must be one of %v", languages)
+	}
+	for i, p := range r.Preferences {
+		if !IsNotificationEvent(p.Event) {
+			errs[fmt.Sprintf("preferences[%d].event", i)] = fmt.Sprintf("unknown event %q", p.Event)
+		}
+		if p.Email != nil && *p.Email && !IsEmailEvent(p.Event) {
+			errs[fmt.Sprintf("preferences[%d].email", i)] = fmt.Sprintf("event %q has no email", p.Event)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// AchievementComment is a discussion message on an achievement.
+type AchievementComment struct {
+	ID            string    `json:"id"`
+	AchievementID string    `json:"achievement_id"`
+	UserID        string    `json:"user_id"`
+	AuthorName    string    `json:"author_name"`
+	AuthorRole    string    `json:"author_role"`
+	Body          string    `json:"body"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type CreateCommentRequest struct {
+	Body string `json:"body"`
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// SortField is one entry of ?sort=-created_at,title ("-" = descending).
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// PageRequest describes one page of a cursor-paginated list.
+type PageRequest struct {
+	Limit     int
+	Cursor    string
+	Sort      []SortField
+	WithTotal bool // ?total=true, hitung total baris (query COUNT tambahan)
+}
+
+// PageResponse is the common envelope of every list endpoint.
+type PageResponse[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// PageError is returned for an invalid sort field or cursor (HTTP 400).
+type PageError struct {
+	Message string
+}
+
+func (e *PageError) Error() string { return e.Message }
+
+// ParseSort parses "-created_at,title" into sort fields.
+func ParseSort(raw string) []SortField {
+	var out []SortField
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		f := SortField{Field: strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")}
+		f.Desc = strings.HasPrefix(part, "-")
+		out = append(out, f)
+	}
+	return out
+}
+
+// SortSpec is the canonical string form of sort, stored in cursors.
+func SortSpec(sort []SortField) string {
+	parts := make([]string, len(sort))
+	for i, f := range sort {
+		if f.Desc {
+			parts[i] = "-" + f.Field
+		} else {
+			parts[i] = f.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// pageCursor adalah isi cursor: urutan yang dipakai dan nilai kunci baris terakhir.
+type pageCursor struct {
+	Sort string   `json:"s"`
+	Keys []string `json:"k"`
+}
+
+func EncodeCursor(sort []SortField, keys []string) string {
+	b, _ := json.Marshal(pageCursor{Sort: SortSpec(sort), Keys: keys})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor returns the key values of cursor; it must have been issued
+// for the same sort order and have want keys.
+func DecodeCursor(cursor string, sort []SortField, want int) ([]string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, &PageError{Message: "invalid cursor"}
+	}
+	var pc pageCursor
+	if err := json.Unmarshal(b, &pc); err != nil || len(pc.Keys) != want {
+		return nil, &PageError{Message: "invalid cursor"}
+	}
+	if pc.Sort != SortSpec(sort) {
+		return nil, &PageError{Message: "cursor was issued for a different sort order"}
+	}
+	return pc.Keys, nil
+}
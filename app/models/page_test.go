@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	got := ParseSort(" -created_at, +title ,,name")
+	want := []SortField{{Field: "created_at", Desc: true}, {Field: "title"}, {Field: "name"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseSort = %+v, want %+v", got, want)
+	}
+	if s := SortSpec(got); s != "-created_at,title,name" {
+		t.Fatalf("SortSpec = %q", s)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	sort := []SortField{{Field: "created_at", Desc: true}}
+	keys := []string{"2025-01-02 03:04:05+00", "0b0e4c1e-2a6f-4a55-9d8e-2b3c4d5e6f70"}
+	cursor := EncodeCursor(sort, keys)
+	got, err := DecodeCursor(cursor, sort, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, keys) {
+		t.Fatalf("keys = %v, want %v", got, keys)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	sort := []SortField{{Field: "created_at", Desc: true}}
+	valid := EncodeCursor(sort, []string{"a", "b"})
+	tests := []struct {
+		name   string
+		cursor string
+		sort   []SortField
+		want   int
+	}{
+		{"not base64", "***", sort, 2},
+		{"not json", "bm90IGpzb24", sort, 2},
+		{"wrong key count", valid, sort, 3},
+		{"other direction", valid, []SortField{{Field: "created_at"}}, 2},
+		{"other field", valid, []SortField{{Field: "title", Desc: true}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCursor(tt.cursor, tt.sort, tt.want)
+			var pe *PageError
+			if !errors.As(err, &pe) {
+				t.Fatalf("err = %v, want *PageError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// PointRule gives Points to achievements of a type/level/rank. An empty Rank
+// matches any rank of that type and level (a more specific rule wins).
+type PointRule struct {
+	ID              string `json:"id"`
+	AchievementType string `json:"achievement_type"`
+	Level           string `json:"level"`
+	Rank            string `json:"rank"`
+	Points          int    `json:"points"`
+}
+
+// PointRuleSet is one version of the rules for an academic year. Only the
+// newest version of a year is active.
+type PointRuleSet struct {
+	ID           string      `json:"id"`
+	AcademicYear string      `json:"academic_year"`
+	Version      int         `json:"version"`
+	CreatedBy    *string     `json:"created_by"`
+	CreatedAt    time.Time   `json:"created_at"`
+	Rules        []PointRule `json:"rules,omitempty"`
+}
+
+// PointsUpdate is the recalculated score of one achievement; RuleSetID nil
+// berarti tahun akademiknya tidak punya aturan poin.
+type PointsUpdate struct {
+	ID        string
+	Points    int
+	RuleSetID *string
+}
+
+type CreatePointRuleSetRequest struct {
+	AcademicYear string      `json:"academic_year"`
+	Rules        []PointRule `json:"rules"`
+}
+
+// AcademicYearOf returns the academic year ("2025-2026") a date falls in;
+// tahun akademik dimulai 1 Agustus.
+func AcademicYearOf(t time.Time) string {
+	y := t.Year()
+	if t.Month() < time.August {
+		y--
+	}
+	return fmt.Sprintf("%d-%d", y, y+1)
+}
+
+// AcademicYearRange returns [start, end) of an academic year like "2025-2026".
+func AcademicYearRange(year string) (time.Time, time.Time, error) {
+	var from, to int
+	if _, err := fmt.Sscanf(year, "%d-%d", &from, &to); err != nil || to != from+1 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid academic year %q (expected e.g. 2025-2026)", year)
+	}
+	start := time.Date(from, time.August, 1, 0, 0, 0, 0, time.Local)
+	return start, start.AddDate(1, 0, 0), nil
+}
+
+// ValidatePointRules checks rule keys against the achievement type registry.
+func ValidatePointRules(rules []PointRule) error {
+	errs := ValidationErrors{}
+	seen := map[string]bool{}
+	for i, r := range rules {
+		key := fmt.Sprintf("rules[%d]", i)
+		t, ok := AchievementTypes[r.AchievementType]
+		if !ok {
+			errs[key+".achievement_type"] = fmt.Sprintf("unknown type %q", r.AchievementType)
+			continue
+		}
+		if !contains(AchievementLevels, r.Level) {
+			errs[key+".level"] = "unknown level"
+		}
+		if r.Rank != "" {
+			var ranks []string
+			for _, f := range t.Fields {
+				if f.Name == "rank" {
+					ranks = f.Values
+				}
+			}
+			if !contains(ranks, r.Rank) {
+				errs[key+".rank"] = "unknown rank for this type"
+			}
+		}
+		if r.Points < 0 {
+			errs[key+".points"] = "must be >= 0"
+		}
+		dup := r.AchievementType + "|" + r.Level + "|" + r.Rank
+		if seen[dup] {
+			errs[key] = "duplicate rule"
+		}
+		seen[dup] = true
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// MatchPointRule returns the points for type/level/rank; exact rank beats a
+// wildcard rule. ok is false when no rule matches.
+func MatchPointRule(rules []PointRule, achievementType, level, rank string) (points int, ok bool) {
+	for _, r := range rules {
+		if r.AchievementType == achievementType && r.Level == level && r.Rank == rank && rank != "" {
+			return r.Points, true
+		}
+	}
+	for _, r := range rules {
+		if r.AchievementType == achievementType && r.Level == level && r.Rank == "" {
+			return r.Points, true
+		}
+	}
+	return 0, false
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
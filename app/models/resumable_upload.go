@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+// ResumableUpload is an in-progress tus upload; isinya ditulis ke file
+// sementara sampai Offset == Length, lalu dilampirkan ke prestasi.
+type ResumableUpload struct {
+	ID            string    `json:"id"`
+	AchievementID string    `json:"achievement_id"`
+	UserID        string    `json:"user_id"`
+	FileName      string    `json:"file_name"`
+	Length        int64     `json:"length"`
+	Offset        int64     `json:"offset"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ParseUploadMetadata decodes a tus Upload-Metadata header:
+// "key base64value,key2 base64value2" (value boleh kosong).
+func ParseUploadMetadata(header string) (map[string]string, bool) {
+	meta := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, " ")
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if key == "" || err != nil {
+			return nil, false
+		}
+		meta[key] = string(decoded)
+	}
+	return meta, true
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Role struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Permission struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type RoleRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type AttachPermissionRequest struct {
+	PermissionID string `json:"permission_id"`
+}
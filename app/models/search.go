@@ -0,0 +1,19 @@
+package models
+
+// SearchableExtraFields are the Extra keys included in the achievements
+// text index (besides title and description).
+var SearchableExtraFields = []string{"event_name", "organiser", "publisher", "authors", "location", "certificate_number", "doi"}
+
+// TextMatch is one hit of the Mongo text search.
+type TextMatch struct {
+	MongoID string
+	Score   float64
+}
+
+type SearchHit struct {
+	Achievement AchievementResponse `json:"achievement"`
+	Score       float64             `json:"score"`
+	// Highlights maps a field (title, description, extra.<key>) to a snippet
+	// with the matched terms wrapped in <mark></mark>.
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Interval yang didukung untuk deret waktu statistik.
+var StatsIntervals = []string{"day", "week", "month"}
+
+func IsStatsInterval(v string) bool {
+	return contains(StatsIntervals, v)
+}
+
+// StatsFilter is the scope of a dashboard; empty fields are ignored.
+type StatsFilter struct {
+	StudentID string
+	AdvisorID string // lecturers.id
+	From      *time.Time
+	To        *time.Time // exclusive, berdasarkan created_at
+}
+
+// StatsBucket is one point of a time series broken down by Key (status, type or level).
+type StatsBucket struct {
+	Period string `json:"period"`
+	Key    string `json:"key"`
+	Count  int    `json:"count"`
+}
+
+type ProgramStudyStats struct {
+	ProgramStudy string `json:"program_study"`
+	Students     int    `json:"students"`
+	Total        int    `json:"total"`
+	Verified     int    `json:"verified"`
+}
+
+type AdvisorStats struct {
+	AdvisorID   string `json:"advisor_id"`
+	AdvisorName string `json:"advisor_name"`
+	Advisees    int    `json:"advisees"`
+	Total       int    `json:"total"`
+	Pending     int    `json:"pending"` // submitted, menunggu verifikasi
+	Verified    int    `json:"verified"`
+	Rejected    int    `json:"rejected"`
+}
+
+type VerificationTimeStats struct {
+	Count        int      `json:"count"`
+	AverageHours *float64 `json:"average_hours"`
+}
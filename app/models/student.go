@@ -3,13 +3,15 @@ package models
 import "time"
 
 type Student struct {
-	ID           string    `json:"id"`
-	UserID       string    `json:"user_id"`
-	StudentID    string    `json:"student_id"`
-	ProgramStudy string    `json:"program_study"`
-	AcademicYear string    `json:"academic_year"`
-	AdvisorID    *string   `json:"advisor_id"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	StudentID     string    `json:"student_id"`
+	ProgramStudy  string    `json:"program_study"`
+	AcademicYear  string    `json:"academic_year"`
+	AdvisorID     *string   `json:"advisor_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	TotalPoints   int       `json:"total_points"`   // jumlah poin prestasi terverifikasi
+	PublicListing bool      `json:"public_listing"` // false = tidak tampil di leaderboard
 }
 
 type CreateStudentRequest struct {
@@ -23,3 +25,14 @@ type CreateStudentRequest struct {
 type UpdateAdvisorRequest struct {
 	AdvisorID string `json:"advisor_id"`
 }
+
+type UpdateListingRequest struct {
+	PublicListing *bool `json:"public_listing"`
+}
+
+// StudentFilter narrows StudentRepository.FindPage; empty fields are ignored.
+type StudentFilter struct {
+	ProgramStudy string
+	AcademicYear string
+	AdvisorID    string
+}
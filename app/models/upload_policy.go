@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tipe file yang bisa diizinkan untuk lampiran (dicek dari magic bytes).
+// Video hanya masuk akal lewat upload resumable dan harus diizinkan admin.
+var SupportedUploadTypes = []string{"application/pdf", "image/jpeg", "image/png", "video/mp4", "video/webm"}
+
+var defaultAllowedUploadTypes = []string{"application/pdf", "image/jpeg", "image/png"}
+
+// DefaultUploadPolicyKey is the achievement_type row used when a type has no
+// policy of its own.
+const DefaultUploadPolicyKey = "default"
+
+const defaultMaxUploadBytes = 10 << 20
+
+// UploadPolicy limits the attachments of one achievement type.
+type UploadPolicy struct {
+	AchievementType string     `json:"achievement_type"`
+	MaxSizeBytes    int64      `json:"max_size_bytes"`
+	AllowedTypes    []string   `json:"allowed_types"`
+	UpdatedBy       *string    `json:"updated_by,omitempty"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"`
+}
+
+// BuiltinUploadPolicy applies when neither the type nor "default" is configured.
+func BuiltinUploadPolicy(achievementType string) UploadPolicy {
+	return UploadPolicy{
+		AchievementType: achievementType,
+		MaxSizeBytes:    defaultMaxUploadBytes,
+		AllowedTypes:    append([]string{}, defaultAllowedUploadTypes...),
+	}
+}
+
+type UpdateUploadPolicyRequest struct {
+	MaxSizeBytes int64    `json:"max_size_bytes"`
+	AllowedTypes []string `json:"allowed_types"`
+}
+
+// ValidateUploadPolicy checks a policy for key (a type name or "default")
+// against maxBytes, the largest file the server accepts.
+func ValidateUploadPolicy(key string, req UpdateUploadPolicyRequest, maxBytes int64) error {
+	errs := ValidationErrors{}
+	if _, ok := AchievementTypes[key]; !ok && key != DefaultUploadPolicyKey {
+		errs["achievement_type"] = "unknown achievement type"
+	}
+	if req.MaxSizeBytes <= 0 {
+		errs["max_size_bytes"] = "must be positive"
+	} else if req.MaxSizeBytes > maxBytes {
+		errs["max_size_bytes"] = fmt.Sprintf("must not exceed the server limit of %d bytes", maxBytes)
+	}
+	if len(req.AllowedTypes) == 0 {
+		errs["allowed_types"] = "required"
+	}
+	for _, t := range req.AllowedTypes {
+		if !contains(SupportedUploadTypes, t) {
+			errs["allowed_types"] = fmt.Sprintf("unsupported type %q", t)
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -12,7 +12,22 @@ type User struct {
 	IsActive     bool      `json:"is_active"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	TokenVersion int       `json:"-"` // klaim tv token baru (user_token_revocations)
 }
+
+// ActorProfile is what AchievementPolicy needs to resolve a caller, dibaca
+// dalam satu query (UserRepository.FindActorProfile).
+type ActorProfile struct {
+	UserFound     bool
+	IsActive      bool
+	RoleID        string
+	RoleName      string
+	FullName      string
+	HasPermission bool   // role punya permission yang diminta
+	StudentID     string // students.id, kosong bila bukan mahasiswa
+	LecturerID    string // lecturers.id, kosong bila bukan dosen
+}
+
 type CreateUserRequest struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
@@ -27,3 +42,16 @@ type UpdateUserRequest struct {
 	FullName string `json:"full_name"`
 	RoleID   string `json:"role_id"`
 }
+
+type UpdateUserStatusRequest struct {
+	IsActive *bool `json:"is_active"`
+}
+
+// UserFilter narrows UserRepository.FindPage; empty fields are ignored.
+type UserFilter struct {
+	RoleID   string
+	IsActive *bool
+	Search   string // username, email atau nama
+	From     *time.Time
+	To       *time.Time
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// VerificationCode is the public code of a verified achievement, dicetak
+// sebagai QR di sertifikat. Satu prestasi punya paling banyak satu kode aktif.
+type VerificationCode struct {
+	Code          string     `json:"code"`
+	AchievementID string     `json:"achievement_id"`
+	IssuedBy      *string    `json:"issued_by"`
+	IssuedAt      time.Time  `json:"issued_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy     *string    `json:"revoked_by,omitempty"`
+	RevokeReason  *string    `json:"revoke_reason,omitempty"`
+	URL           string     `json:"url"`
+}
+
+// Hasil cek publik sebuah kode.
+const (
+	VerificationValid   = "valid"
+	VerificationRevoked = "revoked"
+)
+
+// PublicVerification is what /verify/:code shows to anyone holding the code.
+type PublicVerification struct {
+	Code             string     `json:"code"`
+	Status           string     `json:"status"`
+	StudentName      string     `json:"student_name"`
+	AchievementTitle string     `json:"achievement_title"`
+	AchievementType  string     `json:"achievement_type,omitempty"`
+	VerifiedBy       string     `json:"verified_by"`
+	VerifiedAt       *time.Time `json:"verified_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+
+	MongoAchievementID string `json:"-"`
+	AchievementStatus  string `json:"-"`
+}
+
+type RevokeVerificationRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
+)
+
+type AchievementCommentRepository struct{}
+
+func NewAchievementCommentRepository() *AchievementCommentRepository {
+	return &AchievementCommentRepository{}
+}
+
+// Create stores the comment and its notifications in one transaction.
+func (r *AchievementCommentRepository) Create(cm *models.AchievementComment, notify []models.NotificationDraft) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO achievement_comments (achievement_id, user_id, author_name, author_role, body)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		cm.AchievementID, cm.UserID, cm.AuthorName, cm.AuthorRole, cm.Body).Scan(&cm.ID, &cm.CreatedAt); err != nil {
+		return err
+	}
+	if err := insertNotificationsTx(ctx, tx, notify); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+var commentSort = map[string]sortColumn{
+	"created_at": {"cm.created_at", "timestamptz"},
+}
+
+// FindPage returns one page of the comments of an achievement (oldest first by default).
+func (r *AchievementCommentRepository) FindPage(achievementID string, p models.PageRequest) (*models.PageResponse[models.AchievementComment], error) {
+	q := &pageQuery{
+		columns:     `cm.id, cm.achievement_id, cm.user_id, cm.author_name, cm.author_role, cm.body, cm.created_at`,
+		from:        `FROM achievement_comments cm`,
+		sortable:    commentSort,
+		defaultSort: []models.SortField{{Field: "created_at"}},
+		key:         sortColumn{"cm.id", "uuid"},
+	}
+	q.filter("cm.achievement_id = $%d", achievementID)
+	return queryPage(q, p, func(rows pgx.Rows, key *[]string) (models.AchievementComment, error) {
+		cm := models.AchievementComment{}
+		err := rows.Scan(&cm.ID, &cm.AchievementID, &cm.UserID, &cm.AuthorName, &cm.AuthorRole, &cm.Body, &cm.CreatedAt, key)
+		return cm, err
+	})
+}
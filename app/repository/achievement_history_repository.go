@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// AchievementHistoryRepository is the only writer/reader of
+// achievement_reference_history. Writes happen inside the status-change
+// transaction (see AchievementRepository.TransitionStatus).
+type AchievementHistoryRepository struct{}
+
+func NewAchievementHistoryRepository() *AchievementHistoryRepository {
+	return &AchievementHistoryRepository{}
+}
+
+// insertHistoryTx writes a history row using the caller's transaction.
+func insertHistoryTx(ctx context.Context, tx pgx.Tx, achievementID, oldStatus, newStatus string, meta models.HistoryMeta) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO achievement_reference_history
+		 (achievement_ref_id, old_status, new_status, changed_by, actor_name, actor_role, note, ip, user_agent, request_id, changed_at)
+		 VALUES ($1, $2, $3, NULLIF($4, '')::uuid, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), now())`,
+		achievementID, oldStatus, newStatus, meta.ActorID, meta.ActorName, meta.ActorRole,
+		meta.Note, meta.IP, meta.UserAgent, meta.RequestID)
+	return err
+}
+
+var historySort = map[string]sortColumn{
+	"changed_at": {"h.changed_at", "timestamptz"},
+}
+
+// FindPage returns one page of the history of an achievement (newest first by default).
+func (r *AchievementHistoryRepository) FindPage(achievementID string, p models.PageRequest) (*models.PageResponse[models.AchievementHistory], error) {
+	q := &pageQuery{
+		columns:     `h.id, h.achievement_ref_id, h.old_status, h.new_status, h.changed_by, h.actor_name, h.actor_role, h.note, h.ip, h.user_agent, h.request_id, h.changed_at`,
+		from:        `FROM achievement_reference_history h`,
+		sortable:    historySort,
+		defaultSort: []models.SortField{{Field: "changed_at", Desc: true}},
+		key:         sortColumn{"h.id", "uuid"},
+	}
+	q.filter("h.achievement_ref_id = $%d", achievementID)
+	return queryPage(q, p, func(rows pgx.Rows, key *[]string) (models.AchievementHistory, error) {
+		var h models.AchievementHistory
+		err := rows.Scan(&h.ID, &h.AchievementRefID, &h.OldStatus, &h.NewStatus, &h.ChangedBy,
+			&h.ActorName, &h.ActorRole, &h.Note, &h.IP, &h.UserAgent, &h.RequestID, &h.ChangedAt, key)
+		return h, err
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/Lutfania/ekrp/app/models"
 	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
 )
 
 type AchievementRepository struct{}
@@ -15,28 +16,17 @@ func NewAchievementRepository() *AchievementRepository {
 	return &AchievementRepository{}
 }
 
-func (r *AchievementRepository) Create(ar *models.AchievementReference) error {
-	query := `INSERT INTO achievement_references
-	(id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at)
-	VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9)`
-	_, err := config.DB.Exec(context.Background(), query,
-		ar.StudentID, ar.MongoAchievementID, ar.Status,
-		nil, nil, nil, ar.RejectionNote,
-		ar.CreatedAt, ar.UpdatedAt,
-	)
-	return err
-}
+// achievementColumns is the column list scanAchievement expects.
+const achievementColumns = `ar.id, ar.student_id, ar.mongo_achievement_id, ar.status, COALESCE(ar.achievement_type, ''),
+	ar.submitted_at, ar.verified_at, ar.verified_by, ar.rejection_note, ar.created_at, ar.updated_at, ar.points`
 
-func (r *AchievementRepository) FindByID(id string) (*models.AchievementReference, error) {
-	query := `SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at
-	 FROM achievement_references WHERE id = $1 LIMIT 1`
-	row := config.DB.QueryRow(context.Background(), query, id)
+func scanAchievement(row pgx.Row) (*models.AchievementReference, error) {
 	ar := &models.AchievementReference{}
 	var submittedAt, verifiedAt sql.NullTime
 	var verifiedBy, rejectionNote sql.NullString
 	var updatedAt sql.NullTime
-	err := row.Scan(&ar.ID, &ar.StudentID, &ar.MongoAchievementID, &ar.Status,
-		&submittedAt, &verifiedAt, &verifiedBy, &rejectionNote, &ar.CreatedAt, &updatedAt)
+	err := row.Scan(&ar.ID, &ar.StudentID, &ar.MongoAchievementID, &ar.Status, &ar.AchievementType,
+		&submittedAt, &verifiedAt, &verifiedBy, &rejectionNote, &ar.CreatedAt, &updatedAt, &ar.Points)
 	if err != nil {
 		return nil, err
 	}
@@ -60,88 +50,219 @@ func (r *AchievementRepository) FindByID(id string) (*models.AchievementReferenc
 	return ar, nil
 }
 
-func (r *AchievementRepository) ListAll() ([]models.AchievementReference, error) {
-	query := `SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at FROM achievement_references ORDER BY created_at DESC`
-	rows, err := config.DB.Query(context.Background(), query)
+func (r *AchievementRepository) Create(ar *models.AchievementReference) error {
+	query := `INSERT INTO achievement_references
+	(id, student_id, mongo_achievement_id, status, achievement_type, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at)
+	VALUES (gen_random_uuid(), $1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10)
+	RETURNING id`
+	return config.DB.QueryRow(context.Background(), query,
+		ar.StudentID, ar.MongoAchievementID, ar.Status, ar.AchievementType,
+		nil, nil, nil, ar.RejectionNote,
+		ar.CreatedAt, ar.UpdatedAt,
+	).Scan(&ar.ID)
+}
+
+// FindByID returns a non-deleted achievement; soft-deleted ones are not found.
+func (r *AchievementRepository) FindByID(id string) (*models.AchievementReference, error) {
+	query := `SELECT ` + achievementColumns + `
+	 FROM achievement_references ar WHERE ar.id = $1 AND ar.status <> 'deleted' LIMIT 1`
+	return scanAchievement(config.DB.QueryRow(context.Background(), query, id))
+}
+
+// achievementSort lists the fields FindPage can sort by.
+var achievementSort = map[string]sortColumn{
+	"created_at": {"ar.created_at", "timestamptz"},
+	"updated_at": {"COALESCE(ar.updated_at, ar.created_at)", "timestamptz"},
+	"status":     {"ar.status", "text"},
+	"type":       {"COALESCE(ar.achievement_type, '')", "text"},
+	"points":     {"COALESCE(ar.points, 0)", "int"},
+}
+
+// FindPage returns one page of non-deleted achievements matching f.
+func (r *AchievementRepository) FindPage(f models.AchievementFilter, p models.PageRequest) (*models.PageResponse[models.AchievementReference], error) {
+	q := &pageQuery{
+		columns:     achievementColumns,
+		from:        `FROM achievement_references ar`,
+		where:       []string{"ar.status <> 'deleted'"},
+		sortable:    achievementSort,
+		defaultSort: []models.SortField{{Field: "created_at", Desc: true}},
+		key:         sortColumn{"ar.id", "uuid"},
+	}
+	if f.StudentID != "" {
+		q.filter("ar.student_id = $%d", f.StudentID)
+	}
+	if f.AdvisorID != "" {
+		q.from += ` JOIN students s ON s.id = ar.student_id`
+		q.filter("s.advisor_id = $%d", f.AdvisorID)
+	}
+	if f.Type != "" {
+		q.filter("ar.achievement_type = $%d", f.Type)
+	}
+	if len(f.Statuses) > 0 {
+		q.filter("ar.status = ANY($%d)", f.Statuses)
+	}
+	if f.From != nil {
+		q.filter("ar.created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		q.filter("ar.created_at < $%d", *f.To)
+	}
+	return queryPage(q, p, func(rows pgx.Rows, key *[]string) (models.AchievementReference, error) {
+		ar, err := scanAchievement(keyedRow{rows, key})
+		if err != nil {
+			return models.AchievementReference{}, err
+		}
+		return *ar, nil
+	})
+}
+
+// FindByMongoIDs returns the non-deleted achievements whose Mongo document is
+// in mongoIDs and that match f (dipakai pencarian teks).
+func (r *AchievementRepository) FindByMongoIDs(mongoIDs []string, f models.AchievementFilter) ([]models.AchievementReference, error) {
+	q := &pageQuery{from: `FROM achievement_references ar`, where: []string{"ar.status <> 'deleted'"}}
+	q.filter("ar.mongo_achievement_id = ANY($%d)", mongoIDs)
+	if f.StudentID != "" {
+		q.filter("ar.student_id = $%d", f.StudentID)
+	}
+	if f.AdvisorID != "" {
+		q.from += ` JOIN students s ON s.id = ar.student_id`
+		q.filter("s.advisor_id = $%d", f.AdvisorID)
+	}
+	if f.Type != "" {
+		q.filter("ar.achievement_type = $%d", f.Type)
+	}
+	if len(f.Statuses) > 0 {
+		q.filter("ar.status = ANY($%d)", f.Statuses)
+	}
+	rows, err := config.DB.Query(context.Background(), `SELECT `+achievementColumns+` `+q.from+q.whereClause(), q.args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var res []models.AchievementReference
 	for rows.Next() {
-		var ar models.AchievementReference
-		var submittedAt, verifiedAt sql.NullTime
-		var verifiedBy, rejectionNote sql.NullString
-		var updatedAt sql.NullTime
-		if err := rows.Scan(&ar.ID, &ar.StudentID, &ar.MongoAchievementID, &ar.Status,
-			&submittedAt, &verifiedAt, &verifiedBy, &rejectionNote, &ar.CreatedAt, &updatedAt); err != nil {
+		ar, err := scanAchievement(rows)
+		if err != nil {
 			return nil, err
 		}
-		if submittedAt.Valid {
-			ar.SubmittedAt = &submittedAt.Time
-		}
-		if verifiedAt.Valid {
-			ar.VerifiedAt = &verifiedAt.Time
-		}
-		if verifiedBy.Valid {
-			v := verifiedBy.String
-			ar.VerifiedBy = &v
-		}
-		if rejectionNote.Valid {
-			n := rejectionNote.String
-			ar.RejectionNote = &n
+		res = append(res, *ar)
+	}
+	return res, rows.Err()
+}
+
+// TransitionStatus reads the current status with a row lock, checks the state
+// machine, applies the change and writes the history row in one transaction.
+// It returns the previous status, or a *models.TransitionError if the move is
+// not allowed.
+func (r *AchievementRepository) TransitionStatus(id string, change models.StatusChange, meta models.HistoryMeta) (string, error) {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+	if err := tx.QueryRow(ctx,
+		`SELECT status FROM achievement_references WHERE id=$1 FOR UPDATE`, id).Scan(&current); err != nil {
+		return "", err
+	}
+	if !models.CanTransition(current, change.To) {
+		return current, &models.TransitionError{From: current, To: change.To, Allowed: models.AllowedTransitions(current)}
+	}
+
+	query := `UPDATE achievement_references SET status=$1,
+		submitted_at=COALESCE($2, submitted_at),
+		verified_at=COALESCE($3, CASE WHEN $10 THEN NULL ELSE verified_at END),
+		verified_by=COALESCE($4, CASE WHEN $10 THEN NULL ELSE verified_by END),
+		rejection_note=COALESCE($5, CASE WHEN $10 THEN NULL ELSE rejection_note END), updated_at=$6,
+		points=COALESCE($8, points),
+		point_rule_set_id=CASE WHEN $8::int IS NULL THEN point_rule_set_id ELSE $9 END
+		WHERE id=$7`
+	if _, err := tx.Exec(ctx, query, change.To, change.SubmittedAt, change.VerifiedAt,
+		change.VerifiedBy, change.RejectionNote, time.Now(), id, change.Points, change.PointRuleSetID,
+		change.ResetReview); err != nil {
+		return "", err
+	}
+	if err := insertHistoryTx(ctx, tx, id, current, change.To, meta); err != nil {
+		return "", err
+	}
+	if change.VerificationCode != "" {
+		if err := insertVerificationCodeTx(ctx, tx, id, change.VerificationCode, meta.ActorID); err != nil {
+			return "", err
 		}
-		if updatedAt.Valid {
-			ar.UpdatedAt = &updatedAt.Time
+	}
+	if change.Notify != nil {
+		if err := insertNotificationsTx(ctx, tx, change.Notify()); err != nil {
+			return "", err
 		}
-		res = append(res, ar)
 	}
-	return res, nil
+	return current, tx.Commit(ctx)
 }
 
-func (r *AchievementRepository) ListByStudent(studentID string) ([]models.AchievementReference, error) {
-	query := `SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at FROM achievement_references WHERE student_id=$1 ORDER BY created_at DESC`
-	rows, err := config.DB.Query(context.Background(), query, studentID)
+// Participants returns the student who owns the achievement and their
+// advisor (nil if the student has no advisor). Hanya user aktif yang dikembalikan.
+func (r *AchievementRepository) Participants(id string) (student, advisor *models.Recipient, err error) {
+	var su, se, sn, sl, au, ae, an, al sql.NullString
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT su.id::text, su.email, su.full_name, su.language,
+		        au.id::text, au.email, au.full_name, au.language
+		 FROM achievement_references ar
+		 JOIN students s ON s.id = ar.student_id
+		 LEFT JOIN users su ON su.id = s.user_id AND su.is_active
+		 LEFT JOIN lecturers l ON l.id = s.advisor_id
+		 LEFT JOIN users au ON au.id = l.user_id AND au.is_active
+		 WHERE ar.id = $1`, id).Scan(&su, &se, &sn, &sl, &au, &ae, &an, &al)
+	if err != nil {
+		return nil, nil, err
+	}
+	if su.Valid {
+		student = &models.Recipient{UserID: su.String, Email: se.String, FullName: sn.String, Language: sl.String}
+	}
+	if au.Valid {
+		advisor = &models.Recipient{UserID: au.String, Email: ae.String, FullName: an.String, Language: al.String}
+	}
+	return student, advisor, nil
+}
+
+// ListVerifiedBetween returns verified (or archived) achievements whose
+// verified_at falls in [from, to).
+func (r *AchievementRepository) ListVerifiedBetween(from, to time.Time) ([]models.AchievementReference, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT `+achievementColumns+` FROM achievement_references ar
+		 WHERE ar.status IN ('verified', 'archived') AND ar.verified_at >= $1 AND ar.verified_at < $2`, from, to)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var res []models.AchievementReference
 	for rows.Next() {
-		var ar models.AchievementReference
-		var submittedAt, verifiedAt sql.NullTime
-		var verifiedBy, rejectionNote sql.NullString
-		var updatedAt sql.NullTime
-		if err := rows.Scan(&ar.ID, &ar.StudentID, &ar.MongoAchievementID, &ar.Status,
-			&submittedAt, &verifiedAt, &verifiedBy, &rejectionNote, &ar.CreatedAt, &updatedAt); err != nil {
+		ar, err := scanAchievement(rows)
+		if err != nil {
 			return nil, err
 		}
-		if submittedAt.Valid {
-			ar.SubmittedAt = &submittedAt.Time
-		}
-		if verifiedAt.Valid {
-			ar.VerifiedAt = &verifiedAt.Time
-		}
-		if verifiedBy.Valid {
-			v := verifiedBy.String
-			ar.VerifiedBy = &v
-		}
-		if rejectionNote.Valid {
-			n := rejectionNote.String
-			ar.RejectionNote = &n
-		}
-		if updatedAt.Valid {
-			ar.UpdatedAt = &updatedAt.Time
-		}
-		res = append(res, ar)
+		res = append(res, *ar)
 	}
-	return res, nil
+	return res, rows.Err()
 }
 
-func (r *AchievementRepository) UpdateStatus(id, status string, submittedAt, verifiedAt *time.Time, verifiedBy *string, rejectionNote *string) error {
-	query := `UPDATE achievement_references SET status=$1, submitted_at=$2, verified_at=$3, verified_by=$4, rejection_note=$5, updated_at=$6 WHERE id=$7`
-	_, err := config.DB.Exec(context.Background(), query, status, submittedAt, verifiedAt, verifiedBy, rejectionNote, time.Now(), id)
-	return err
+// SetPoints stores the computed points and the rule set they came from, all
+// in one transaction.
+func (r *AchievementRepository) SetPoints(updates []models.PointsUpdate) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for _, u := range updates {
+		if _, err := tx.Exec(ctx,
+			`UPDATE achievement_references SET points=$1, point_rule_set_id=$2 WHERE id=$3`,
+			u.Points, u.RuleSetID, u.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
 }
 
 func (r *AchievementRepository) UpdateMongoID(id, mongoID string) error {
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
+)
+
+type AuditRepository struct{}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+const insertAuditSQL = `INSERT INTO audit_logs (actor_id, action, entity_type, entity_id, details, ip)
+	VALUES (NULLIF($1, '')::uuid, $2, $3, $4, $5, NULLIF($6, ''))`
+
+// Log writes one audit entry. actorID/ip may be empty.
+func (r *AuditRepository) Log(actorID, action, entityType, entityID string, details map[string]interface{}, ip string) error {
+	_, err := config.DB.Exec(context.Background(), insertAuditSQL,
+		actorID, action, entityType, entityID, details, ip)
+	return err
+}
+
+// insertAuditTx writes e using the caller's transaction, so the entry commits
+// or rolls back together with the change.
+func insertAuditTx(ctx context.Context, tx pgx.Tx, e models.AuditEntry) error {
+	_, err := tx.Exec(ctx, insertAuditSQL, e.ActorID, e.Action, e.EntityType, e.EntityID, e.Details, e.IP)
+	return err
+}
+
+// ListByEntityType returns the latest entries for an entity type (e.g. "role").
+func (r *AuditRepository) ListByEntityType(entityType string, limit int) ([]models.AuditLog, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id, actor_id, action, entity_type, entity_id, details, ip, created_at
+		 FROM audit_logs WHERE entity_type = $1 ORDER BY created_at DESC LIMIT $2`, entityType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.AuditLog{}
+	for rows.Next() {
+		var a models.AuditLog
+		if err := rows.Scan(&a.ID, &a.ActorID, &a.Action, &a.EntityType, &a.EntityID, &a.Details, &a.IP, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"crypto/ed25519"
+
+	"github.com/Lutfania/ekrp/config"
+	"github.com/Lutfania/ekrp/credential"
+	"github.com/jackc/pgx/v5"
+)
+
+// CredentialKeyRepository stores the signing keys; private key disimpan
+// terenkripsi (credential.SealPrivateKey) dan tidak pernah dihapus dari
+// daftar publik supaya kredensial lama tetap bisa diverifikasi.
+type CredentialKeyRepository struct{}
+
+func NewCredentialKeyRepository() *CredentialKeyRepository {
+	return &CredentialKeyRepository{}
+}
+
+// FindAll returns the public part of every key, newest first.
+func (r *CredentialKeyRepository) FindAll() ([]credential.Key, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT kid, public_key, created_at, retired_at FROM credential_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []credential.Key{}
+	for rows.Next() {
+		var k credential.Key
+		var pub []byte
+		if err := rows.Scan(&k.ID, &pub, &k.CreatedAt, &k.RetiredAt); err != nil {
+			return nil, err
+		}
+		k.Public = ed25519.PublicKey(pub)
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// Active returns the signing key and its sealed private key (pgx.ErrNoRows if none).
+func (r *CredentialKeyRepository) Active() (*credential.Key, []byte, error) {
+	var k credential.Key
+	var pub, sealed []byte
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT kid, public_key, private_key, created_at FROM credential_keys WHERE retired_at IS NULL`).
+		Scan(&k.ID, &pub, &sealed, &k.CreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	k.Public = ed25519.PublicKey(pub)
+	return &k, sealed, nil
+}
+
+// ActiveID returns the kid of the signing key (pgx.ErrNoRows if none).
+func (r *CredentialKeyRepository) ActiveID() (string, error) {
+	var kid string
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT kid FROM credential_keys WHERE retired_at IS NULL`).Scan(&kid)
+	return kid, err
+}
+
+// CreateIfNone inserts k as the first active key; false if another process
+// already created one (unique index pada kunci aktif).
+func (r *CredentialKeyRepository) CreateIfNone(k *credential.Key, sealed []byte, createdBy string) (bool, error) {
+	tag, err := config.DB.Exec(context.Background(),
+		`INSERT INTO credential_keys (kid, public_key, private_key, created_by, created_at)
+		 VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5) ON CONFLICT DO NOTHING`,
+		k.ID, []byte(k.Public), sealed, createdBy, k.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Rotate retires the active key and makes k the new one, in one transaction.
+func (r *CredentialKeyRepository) Rotate(k *credential.Key, sealed []byte, createdBy string) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `UPDATE credential_keys SET retired_at = now() WHERE retired_at IS NULL`); err != nil {
+		return err
+	}
+	if err := insertCredentialKeyTx(ctx, tx, k, sealed, createdBy); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func insertCredentialKeyTx(ctx context.Context, tx pgx.Tx, k *credential.Key, sealed []byte, createdBy string) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO credential_keys (kid, public_key, private_key, created_by, created_at)
+		 VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5)`,
+		k.ID, []byte(k.Public), sealed, createdBy, k.CreatedAt)
+	return err
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
+)
+
+// EmailOutboxRepository is the email queue. Baris ditulis dalam transaksi
+// perubahan status (insertEmailTx) lalu dikirim oleh service.EmailWorker.
+type EmailOutboxRepository struct{}
+
+func NewEmailOutboxRepository() *EmailOutboxRepository {
+	return &EmailOutboxRepository{}
+}
+
+const outboxColumns = `id, user_id::text, event, achievement_id::text, to_address, to_name, subject, text_body, html_body,
+	status, attempts, next_attempt_at, last_error, created_at, sent_at`
+
+func scanOutboxEmail(row pgx.Row, extra ...interface{}) (*models.OutboxEmail, error) {
+	e := &models.OutboxEmail{}
+	dest := []interface{}{&e.ID, &e.UserID, &e.Event, &e.AchievementID, &e.ToAddress, &e.ToName, &e.Subject,
+		&e.TextBody, &e.HTMLBody, &e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError, &e.CreatedAt, &e.SentAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// insertEmailTx queues the email of d using the caller's transaction, kecuali
+// penerima mematikan email untuk event ini.
+func insertEmailTx(ctx context.Context, tx pgx.Tx, d models.NotificationDraft) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO email_outbox (user_id, event, achievement_id, to_address, to_name, subject, text_body, html_body)
+		 SELECT $1::uuid, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM notification_preferences p
+			WHERE p.user_id = $1::uuid AND p.event = $2 AND NOT p.email)`,
+		d.UserID, d.Event, d.AchievementID, d.Email.ToAddress, d.Email.ToName,
+		d.Email.Subject, d.Email.TextBody, d.Email.HTMLBody)
+	return err
+}
+
+// Claim picks up to limit due emails and leases them for lease: attempts
+// dinaikkan dan next_attempt_at digeser, jadi bila worker mati di tengah
+// pengiriman email dicoba lagi setelah lease habis. SKIP LOCKED membuat
+// beberapa instance aman berjalan bersamaan, selama email dikirim sebelum
+// lease-nya habis (EmailWorker.drain).
+func (r *EmailOutboxRepository) Claim(limit int, lease time.Duration) ([]models.OutboxEmail, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`UPDATE email_outbox SET attempts = attempts + 1, next_attempt_at = now() + make_interval(secs => $2)
+		 WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at LIMIT $1
+			FOR UPDATE SKIP LOCKED)
+		 RETURNING `+outboxColumns, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.OutboxEmail{}
+	for rows.Next() {
+		e, err := scanOutboxEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+// Release gives claimed but unsent emails back to the queue without counting
+// the attempt.
+func (r *EmailOutboxRepository) Release(ids []string) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE email_outbox SET attempts = attempts - 1, next_attempt_at = now()
+		 WHERE id = ANY($1::uuid[]) AND status = 'pending'`, ids)
+	return err
+}
+
+func (r *EmailOutboxRepository) MarkSent(id string) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE email_outbox SET status = 'sent', sent_at = now(), last_error = NULL WHERE id = $1`, id)
+	return err
+}
+
+// Reschedule records a failed attempt; the email is retried at next.
+func (r *EmailOutboxRepository) Reschedule(id string, next time.Time, lastError string) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE email_outbox SET next_attempt_at = $2, last_error = $3 WHERE id = $1`, id, next, lastError)
+	return err
+}
+
+// MarkFailed gives up on the email after the last attempt.
+func (r *EmailOutboxRepository) MarkFailed(id, lastError string) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE email_outbox SET status = 'failed', last_error = $2 WHERE id = $1`, id, lastError)
+	return err
+}
+
+// Retry puts a failed email back in the queue; false if it is not failed.
+func (r *EmailOutboxRepository) Retry(id string) (bool, error) {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE email_outbox SET status = 'pending', attempts = 0, next_attempt_at = now()
+		 WHERE id = $1 AND status = 'failed'`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+var outboxSort = map[string]sortColumn{
+	"created_at":      {"created_at", "timestamptz"},
+	"next_attempt_at": {"next_attempt_at", "timestamptz"},
+}
+
+// FindPage returns one page of the outbox (newest first by default).
+func (r *EmailOutboxRepository) FindPage(f models.OutboxFilter, p models.PageRequest) (*models.PageResponse[models.OutboxEmail], error) {
+	q := &pageQuery{
+		columns:     outboxColumns,
+		from:        `FROM email_outbox`,
+		sortable:    outboxSort,
+		defaultSort: []models.SortField{{Field: "created_at", Desc: true}},
+		key:         sortColumn{"id", "uuid"},
+	}
+	if f.Status != "" {
+		q.filter("status = $%d", f.Status)
+	}
+	return queryPage(q, p, func(rows pgx.Rows, key *[]string) (models.OutboxEmail, error) {
+		e, err := scanOutboxEmail(rows, key)
+		if err != nil {
+			return models.OutboxEmail{}, err
+		}
+		return *e, nil
+	})
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+)
+
+// Dimensi yang boleh dipakai untuk TopByGroup (nilai -> ekspresi SQL).
+var leaderboardDimensions = map[string]string{
+	"program_study": "s.program_study",
+	"academic_year": "s.academic_year",
+	"category":      "COALESCE(ar.achievement_type, '')",
+}
+
+type LeaderboardRepository struct{}
+
+func NewLeaderboardRepository() *LeaderboardRepository {
+	return &LeaderboardRepository{}
+}
+
+// IsLeaderboardDimension reports whether dim can be used with TopByGroup.
+func IsLeaderboardDimension(dim string) bool {
+	_, ok := leaderboardDimensions[dim]
+	return ok
+}
+
+// scoresCTE builds the per-student score query; groupExpr "" means no grouping.
+func scoresCTE(f models.LeaderboardFilter, groupExpr string) (string, []interface{}) {
+	where := []string{"ar.status IN ('verified', 'archived')", "s.public_listing"}
+	args := []interface{}{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.ProgramStudy != "" {
+		add("s.program_study = $%d", f.ProgramStudy)
+	}
+	if f.AcademicYear != "" {
+		add("s.academic_year = $%d", f.AcademicYear)
+	}
+	if f.Category != "" {
+		add("ar.achievement_type = $%d", f.Category)
+	}
+	if f.From != nil {
+		add("ar.verified_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("ar.verified_at < $%d", *f.To)
+	}
+
+	group := "''"
+	if groupExpr != "" {
+		group = groupExpr
+	}
+	q := `WITH scores AS (
+		SELECT ` + group + ` AS grp, s.id, s.student_id AS nim, u.full_name, s.program_study, s.academic_year,
+		       SUM(COALESCE(ar.points, 0))::int AS total_points, COUNT(*)::int AS achievements,
+		       COALESCE((ARRAY_AGG(ar.mongo_achievement_id ORDER BY ar.points DESC NULLS LAST, ar.verified_at, ar.id))[1], '') AS top_mongo_id
+		FROM achievement_references ar
+		JOIN students s ON s.id = ar.student_id
+		JOIN users u ON u.id = s.user_id
+		WHERE ` + strings.Join(where, " AND ") + `
+		GROUP BY grp, s.id, s.student_id, u.full_name, s.program_study, s.academic_year
+	)`
+	return q, args
+}
+
+// Ranking returns one page of the overall ranking and the number of ranked
+// students. Ties share a rank; order within a tie is by NIM.
+func (r *LeaderboardRepository) Ranking(f models.LeaderboardFilter, limit, offset int) ([]models.LeaderboardEntry, int, error) {
+	cte, args := scoresCTE(f, "")
+	args = append(args, limit, offset)
+	q := cte + fmt.Sprintf(`
+		SELECT RANK() OVER (ORDER BY total_points DESC, achievements DESC)::int, '', id, nim, full_name,
+		       program_study, academic_year, total_points, achievements, top_mongo_id, COUNT(*) OVER ()::int
+		FROM scores
+		ORDER BY total_points DESC, achievements DESC, nim ASC
+		LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	out, total, err := r.query(q, args)
+	if err != nil || len(out) > 0 || offset == 0 {
+		return out, total, err
+	}
+	// halaman di luar jangkauan: COUNT(*) OVER () tidak punya baris, hitung terpisah
+	err = config.DB.QueryRow(context.Background(), cte+` SELECT COUNT(*)::int FROM scores`, args[:len(args)-2]...).Scan(&total)
+	return out, total, err
+}
+
+// TopByGroup returns the top perGroup students for every value of dim.
+func (r *LeaderboardRepository) TopByGroup(dim string, f models.LeaderboardFilter, perGroup int) ([]models.LeaderboardEntry, error) {
+	expr, ok := leaderboardDimensions[dim]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard dimension %q", dim)
+	}
+	cte, args := scoresCTE(f, expr)
+	args = append(args, perGroup)
+	q := cte + fmt.Sprintf(`
+		SELECT rnk, grp, id, nim, full_name, program_study, academic_year, total_points, achievements, top_mongo_id, 0
+		FROM (
+			SELECT *, RANK() OVER (PARTITION BY grp ORDER BY total_points DESC, achievements DESC)::int AS rnk,
+			       ROW_NUMBER() OVER (PARTITION BY grp ORDER BY total_points DESC, achievements DESC, nim ASC) AS rn
+			FROM scores
+		) ranked
+		WHERE rn <= $%d
+		ORDER BY grp, rn`, len(args))
+	out, _, err := r.query(q, args)
+	return out, err
+}
+
+func (r *LeaderboardRepository) query(q string, args []interface{}) ([]models.LeaderboardEntry, int, error) {
+	rows, err := config.DB.Query(context.Background(), q, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := []models.LeaderboardEntry{}
+	total := 0
+	for rows.Next() {
+		var e models.LeaderboardEntry
+		if err := rows.Scan(&e.Rank, &e.Group, &e.StudentID, &e.NIM, &e.FullName, &e.ProgramStudy,
+			&e.AcademicYear, &e.TotalPoints, &e.Achievements, &e.TopMongoID, &total); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, e)
+	}
+	return out, total, rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/Lutfania/ekrp/app/models"
 	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
 )
 
 type LecturerRepository struct{}
@@ -12,24 +13,30 @@ func NewLecturerRepository() *LecturerRepository {
 	return &LecturerRepository{}
 }
 
-// FindAll lecturers
-func (r *LecturerRepository) FindAll() ([]models.Lecturer, error) {
-	rows, err := config.DB.Query(context.Background(),
-		`SELECT id, user_id, lecturer_id, department, created_at FROM lecturers`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+// lecturerSort lists the fields FindPage can sort by.
+var lecturerSort = map[string]sortColumn{
+	"lecturer_id": {"l.lecturer_id", "text"},
+	"department":  {"l.department", "text"},
+	"created_at":  {"l.created_at", "timestamptz"},
+}
 
-	var res []models.Lecturer
-	for rows.Next() {
-		l := models.Lecturer{}
-		if err := rows.Scan(&l.ID, &l.UserID, &l.LecturerID, &l.Department, &l.CreatedAt); err != nil {
-			return nil, err
-		}
-		res = append(res, l)
+// FindPage lecturers
+func (r *LecturerRepository) FindPage(f models.LecturerFilter, p models.PageRequest) (*models.PageResponse[models.Lecturer], error) {
+	q := &pageQuery{
+		columns:     `l.id, l.user_id, l.lecturer_id, l.department, l.created_at`,
+		from:        `FROM lecturers l`,
+		sortable:    lecturerSort,
+		defaultSort: []models.SortField{{Field: "lecturer_id"}},
+		key:         sortColumn{"l.id", "uuid"},
 	}
-	return res, nil
+	if f.Department != "" {
+		q.filter("l.department = $%d", f.Department)
+	}
+	return queryPage(q, p, func(rows pgx.Rows, key *[]string) (models.Lecturer, error) {
+		l := models.Lecturer{}
+		err := rows.Scan(&l.ID, &l.UserID, &l.LecturerID, &l.Department, &l.CreatedAt, key)
+		return l, err
+	})
 }
 
 // FindById lecturer
@@ -43,6 +50,17 @@ func (r *LecturerRepository) FindById(id string) (*models.Lecturer, error) {
 	return l, nil
 }
 
+// FindByUserID returns the lecturer profile linked to a user account.
+func (r *LecturerRepository) FindByUserID(userID string) (*models.Lecturer, error) {
+	row := config.DB.QueryRow(context.Background(),
+		`SELECT id, user_id, lecturer_id, department, created_at FROM lecturers WHERE user_id = $1 LIMIT 1`, userID)
+	l := &models.Lecturer{}
+	if err := row.Scan(&l.ID, &l.UserID, &l.LecturerID, &l.Department, &l.CreatedAt); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
 // Create a lecturer
 func (r *LecturerRepository) Create(l *models.Lecturer) error {
 	_, err := config.DB.Exec(context.Background(),
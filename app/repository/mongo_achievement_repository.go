@@ -2,14 +2,24 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Lutfania/ekrp/app/models"
 	"github.com/Lutfania/ekrp/database" // pastikan path sesuai
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrVersionConflict means the document was edited by someone else since it
+// was read (optimistic locking on the version field).
+var ErrVersionConflict = errors.New("achievement was modified concurrently")
+
+// ErrFileNotFound means no entry of the files array has the given file_id.
+var ErrFileNotFound = errors.New("file not found")
+
 type MongoAchievementRepository struct{}
 
 func NewMongoAchievementRepository() *MongoAchievementRepository {
@@ -22,14 +32,132 @@ func (r *MongoAchievementRepository) Insert(doc *models.MongoAchievement) (strin
 	defer cancel()
 
 	doc.CreatedAt = time.Now()
+	doc.Version = 1
 	res, err := coll.InsertOne(ctx, doc)
 	if err != nil {
 		return "", err
 	}
 	oid := res.InsertedID.(primitive.ObjectID)
+
+	// snapshot versi pertama supaya diff bisa dimulai dari konten awal
+	if err := r.saveVersion(ctx, oid.Hex(), 1, doc, "", doc.CreatedAt, true); err != nil {
+		// jangan tinggalkan dokumen tanpa referensi Postgres
+		_, _ = coll.DeleteOne(ctx, bson.M{"_id": oid})
+		return "", err
+	}
 	return oid.Hex(), nil
 }
 
+// UpdateContent replaces title/description/extra of doc (as read by the caller),
+// bumps its version and stores a snapshot. Returns ErrVersionConflict if doc
+// changed in the meantime.
+//
+// Tanpa transaksi (Mongo standalone tetap didukung): update bersyarat pada
+// version, snapshot di-upsert per (achievement_id, version). Snapshot versi
+// yang sedang dibaca diisi dulu kalau belum ada (dokumen lama, atau penulis
+// sebelumnya gagal setelah update), jadi riwayat versi tidak berlubang.
+func (r *MongoAchievementRepository) UpdateContent(doc *models.MongoAchievement, title, description string, extra map[string]interface{}, editedBy string) (int, error) {
+	oid, ok := doc.ID.(primitive.ObjectID)
+	if !ok {
+		return 0, errors.New("invalid document id")
+	}
+	coll := database.Collection("achievements")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": oid, "version": doc.Version}
+	current := doc.Version
+	if current == 0 {
+		// dokumen lama (sebelum ada versioning): konten awal menjadi versi 1
+		filter = bson.M{"_id": oid, "version": bson.M{"$exists": false}}
+		current = 1
+	}
+	at := doc.CreatedAt
+	if doc.UpdatedAt != nil {
+		at = *doc.UpdatedAt
+	}
+	if err := r.saveVersion(ctx, oid.Hex(), current, doc, "", at, false); err != nil {
+		return 0, err
+	}
+
+	next := current + 1
+	now := time.Now()
+	res, err := coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"title":       title,
+		"description": description,
+		"extra":       extra,
+		"version":     next,
+		"updated_at":  now,
+	}})
+	if err != nil {
+		return 0, err
+	}
+	if res.MatchedCount == 0 {
+		return 0, ErrVersionConflict
+	}
+
+	updated := &models.MongoAchievement{Title: title, Description: description, Extra: extra}
+	if err := r.saveVersion(ctx, oid.Hex(), next, updated, editedBy, now, true); err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+// saveVersion upserts the snapshot (hexID, version). With overwrite=false an
+// existing snapshot is kept, so it only fills a missing one.
+func (r *MongoAchievementRepository) saveVersion(ctx context.Context, hexID string, version int, doc *models.MongoAchievement, editedBy string, at time.Time, overwrite bool) error {
+	snapshot := models.AchievementVersion{
+		AchievementID: hexID,
+		Version:       version,
+		Title:         doc.Title,
+		Description:   doc.Description,
+		Extra:         doc.Extra,
+		EditedBy:      editedBy,
+		EditedAt:      at,
+	}
+	op := "$setOnInsert"
+	if overwrite {
+		op = "$set"
+	}
+	_, err := database.Collection("achievement_versions").UpdateOne(ctx,
+		bson.M{"achievement_id": hexID, "version": version},
+		bson.M{op: snapshot},
+		options.Update().SetUpsert(true))
+	if !overwrite && mongo.IsDuplicateKeyError(err) {
+		// upsert bersamaan untuk versi yang sama: snapshot sudah ada
+		return nil
+	}
+	return err
+}
+
+// ListVersions returns all snapshots of a document, newest first.
+func (r *MongoAchievementRepository) ListVersions(hexID string) ([]models.AchievementVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cur, err := database.Collection("achievement_versions").Find(ctx,
+		bson.M{"achievement_id": hexID},
+		options.Find().SetSort(bson.D{{Key: "version", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	out := []models.AchievementVersion{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *MongoAchievementRepository) FindVersion(hexID string, version int) (*models.AchievementVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var v models.AchievementVersion
+	if err := database.Collection("achievement_versions").
+		FindOne(ctx, bson.M{"achievement_id": hexID, "version": version}).Decode(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
 func (r *MongoAchievementRepository) FindByIDHex(hexID string) (*models.MongoAchievement, error) {
 	oid, err := primitive.ObjectIDFromHex(hexID)
 	if err != nil {
@@ -45,6 +173,189 @@ func (r *MongoAchievementRepository) FindByIDHex(hexID string) (*models.MongoAch
 	return &doc, nil
 }
 
+// FindByIDHexes fetches several documents in one $in query, keyed by hex id.
+// Invalid or missing ids are simply absent from the result. If fields is not
+// empty only those paths (e.g. "title", "extra.level") are loaded.
+func (r *MongoAchievementRepository) FindByIDHexes(hexIDs []string, fields ...string) (map[string]*models.MongoAchievement, error) {
+	oids := make([]primitive.ObjectID, 0, len(hexIDs))
+	for _, h := range hexIDs {
+		if oid, err := primitive.ObjectIDFromHex(h); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	out := make(map[string]*models.MongoAchievement, len(oids))
+	if len(oids) == 0 {
+		return out, nil
+	}
+	coll := database.Collection("achievements")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find()
+	if len(fields) > 0 {
+		projection := bson.M{}
+		for _, f := range fields {
+			projection[f] = 1
+		}
+		opts.SetProjection(projection)
+	}
+	cur, err := coll.Find(ctx, bson.M{"_id": bson.M{"$in": oids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var doc models.MongoAchievement
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		if oid, ok := doc.ID.(primitive.ObjectID); ok {
+			out[oid.Hex()] = &doc
+		}
+	}
+	return out, cur.Err()
+}
+
+// TextSearch runs a $text query and returns up to limit ids ordered by
+// relevance. studentIDs restricts the owners; nil means any student.
+func (r *MongoAchievementRepository) TextSearch(q string, studentIDs []string, skip, limit int64) ([]models.TextMatch, error) {
+	coll := database.Collection("achievements")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"$text": bson.M{"$search": q}}
+	if studentIDs != nil {
+		filter["student_id"] = bson.M{"$in": studentIDs}
+	}
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetSkip(skip).
+		SetLimit(limit)
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.TextMatch{}
+	for cur.Next(ctx) {
+		var row struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Score float64            `bson:"score"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return nil, err
+		}
+		out = append(out, models.TextMatch{MongoID: row.ID.Hex(), Score: row.Score})
+	}
+	return out, cur.Err()
+}
+
+// AddFile appends an attachment to the document's files array.
+func (r *MongoAchievementRepository) AddFile(hexID string, f models.Attachment) error {
+	return r.UpdateByHex(hexID, bson.M{"$push": bson.M{"files": f}})
+}
+
+// SwapFile replaces the files entry old with f, as long as old is still the
+// current revision (file_id dan storage_key sama). Otherwise ErrFileNotFound.
+func (r *MongoAchievementRepository) SwapFile(hexID string, old, f models.Attachment) error {
+	oid, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return err
+	}
+	coll := database.Collection("achievements")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := coll.UpdateOne(ctx,
+		bson.M{"_id": oid, "files": bson.M{"$elemMatch": bson.M{"file_id": old.FileID, "storage_key": old.StorageKey}}},
+		bson.M{"$set": bson.M{"files.$": f}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrFileNotFound
+	}
+	return nil
+}
+
+// RemoveFile removes the files entry with fileID.
+func (r *MongoAchievementRepository) RemoveFile(hexID, fileID string) error {
+	return r.updateFile(hexID, fileID, bson.M{"$pull": bson.M{"files": bson.M{"file_id": fileID}}})
+}
+
+// SetFilePreview records the preview of a file, as long as the file still
+// has storageKey (tidak diganti selama preview dibuat).
+func (r *MongoAchievementRepository) SetFilePreview(hexID, fileID, storageKey, previewKey, status string) error {
+	oid, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return err
+	}
+	coll := database.Collection("achievements")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := coll.UpdateOne(ctx,
+		bson.M{"_id": oid, "files": bson.M{"$elemMatch": bson.M{"file_id": fileID, "storage_key": storageKey}}},
+		bson.M{"$set": bson.M{"files.$.preview_key": previewKey, "files.$.preview_status": status}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrFileNotFound
+	}
+	return nil
+}
+
+// PendingPreviews returns the files that are still waiting for a preview.
+func (r *MongoAchievementRepository) PendingPreviews() ([]models.PreviewJob, error) {
+	coll := database.Collection("achievements")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cur, err := coll.Find(ctx, bson.M{"files.preview_status": models.PreviewPending},
+		options.Find().SetProjection(bson.M{"_id": 1, "files": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []models.PreviewJob
+	for cur.Next(ctx) {
+		var doc models.MongoAchievement
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		oid, ok := doc.ID.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		for _, f := range doc.Files {
+			if f.PreviewStatus == models.PreviewPending {
+				out = append(out, models.PreviewJob{MongoID: oid.Hex(), File: f})
+			}
+		}
+	}
+	return out, cur.Err()
+}
+
+func (r *MongoAchievementRepository) updateFile(hexID, fileID string, update bson.M) error {
+	oid, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return err
+	}
+	coll := database.Collection("achievements")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := coll.UpdateOne(ctx, bson.M{"_id": oid, "files.file_id": fileID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrFileNotFound
+	}
+	return nil
+}
+
 func (r *MongoAchievementRepository) UpdateByHex(hexID string, update bson.M) error {
 	oid, err := primitive.ObjectIDFromHex(hexID)
 	if err != nil {
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
+)
+
+type NotificationRepository struct{}
+
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{}
+}
+
+// insertNotificationsTx writes drafts (and their emails, to the outbox) using
+// the caller's transaction. Draft untuk event yang dimatikan penerimanya
+// (notification_preferences) dilewati per kanal.
+func insertNotificationsTx(ctx context.Context, tx pgx.Tx, drafts []models.NotificationDraft) error {
+	for _, d := range drafts {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO notifications (user_id, event, achievement_id, actor_id, actor_name, title, body)
+			 SELECT $1::uuid, $2, NULLIF($3, '')::uuid, NULLIF($4, '')::uuid, NULLIF($5, ''), $6, $7
+			 WHERE NOT EXISTS (
+				SELECT 1 FROM notification_preferences p
+				WHERE p.user_id = $1::uuid AND p.event = $2 AND NOT p.in_app)`,
+			d.UserID, d.Event, d.AchievementID, d.ActorID, d.ActorName, d.Title, d.Body); err != nil {
+			return err
+		}
+		if d.Email != nil {
+			if err := insertEmailTx(ctx, tx, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var notificationSort = map[string]sortColumn{
+	"created_at": {"n.created_at", "timestamptz"},
+}
+
+// FindPage returns one page of the notifications of f.UserID (newest first by default).
+func (r *NotificationRepository) FindPage(f models.NotificationFilter, p models.PageRequest) (*models.PageResponse[models.Notification], error) {
+	q := &pageQuery{
+		columns:     `n.id, n.user_id, n.event, n.achievement_id::text, n.actor_id::text, n.actor_name, n.title, n.body, n.read_at, n.created_at`,
+		from:        `FROM notifications n`,
+		sortable:    notificationSort,
+		defaultSort: []models.SortField{{Field: "created_at", Desc: true}},
+		key:         sortColumn{"n.id", "uuid"},
+	}
+	q.filter("n.user_id = $%d", f.UserID)
+	if f.Unread {
+		q.where = append(q.where, "n.read_at IS NULL")
+	}
+	if f.Event != "" {
+		q.filter("n.event = $%d", f.Event)
+	}
+	return queryPage(q, p, func(rows pgx.Rows, key *[]string) (models.Notification, error) {
+		n := models.Notification{}
+		err := rows.Scan(&n.ID, &n.UserID, &n.Event, &n.AchievementID, &n.ActorID, &n.ActorName,
+			&n.Title, &n.Body, &n.ReadAt, &n.CreatedAt, key)
+		return n, err
+	})
+}
+
+func (r *NotificationRepository) UnreadCount(userID string) (int, error) {
+	var n int
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*)::int FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
+
+// MarkRead marks one notification of userID as read; false if it does not exist.
+func (r *NotificationRepository) MarkRead(userID, id string) (bool, error) {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE notifications SET read_at = COALESCE(read_at, now()) WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// MarkAllRead marks every unread notification of userID and returns how many.
+func (r *NotificationRepository) MarkAllRead(userID string) (int64, error) {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE notifications SET read_at = now() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Preferences returns the stored preferences of userID by event.
+func (r *NotificationRepository) Preferences(userID string) (map[string]models.NotificationPreference, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT event, in_app, email FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]models.NotificationPreference{}
+	for rows.Next() {
+		var p models.NotificationPreference
+		if err := rows.Scan(&p.Event, &p.InApp, &p.Email); err != nil {
+			return nil, err
+		}
+		out[p.Event] = p
+	}
+	return out, rows.Err()
+}
+
+// SetPreferences applies the updates (nil = unchanged) and, if language is
+// not nil, the user's email language.
+func (r *NotificationRepository) SetPreferences(userID string, language *string, prefs []models.NotificationPreferenceUpdate) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for _, p := range prefs {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO notification_preferences (user_id, event, in_app, email, updated_at)
+			 VALUES ($1, $2, COALESCE($3, true), COALESCE($4, true), now())
+			 ON CONFLICT (user_id, event) DO UPDATE
+			 SET in_app = COALESCE($3, notification_preferences.in_app),
+			     email = COALESCE($4, notification_preferences.email), updated_at = EXCLUDED.updated_at`,
+			userID, p.Event, p.InApp, p.Email); err != nil {
+			return err
+		}
+	}
+	if language != nil {
+		if _, err := tx.Exec(ctx, `UPDATE users SET language = $2 WHERE id = $1`, userID, *language); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Language returns the email language of userID.
+func (r *NotificationRepository) Language(userID string) (string, error) {
+	var lang string
+	err := config.DB.QueryRow(context.Background(), `SELECT language FROM users WHERE id = $1`, userID).Scan(&lang)
+	return lang, err
+}
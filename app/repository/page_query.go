@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// sortColumn is a sortable expression and its SQL type (for casting cursor
+// values back). Expressions must never be NULL; use COALESCE.
+type sortColumn struct {
+	expr string
+	typ  string
+}
+
+// pgTimestampLayouts are the forms of timestamptz::text (DateStyle ISO); the
+// offset is printed as +07, +05:30 or, rarely, with seconds.
+var pgTimestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999-07:00:00",
+}
+
+// validKey reports whether v (a cursor value) can be cast to the column's
+// type, supaya cursor rusak/palsu menjadi 400 dan tidak sampai ke Postgres.
+func (c sortColumn) validKey(v string) bool {
+	switch c.typ {
+	case "uuid":
+		_, err := uuid.Parse(v)
+		return err == nil
+	case "int":
+		_, err := strconv.ParseInt(v, 10, 32)
+		return err == nil
+	case "timestamptz":
+		for _, layout := range pgTimestampLayouts {
+			if _, err := time.Parse(layout, v); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+	return !strings.ContainsRune(v, 0) // text
+}
+
+// pageQuery builds keyset-paginated SELECTs. Every ordering ends with the
+// unique key column so pages are stable even when sort values tie.
+type pageQuery struct {
+	columns     string // kolom yang dibaca fungsi scan
+	from        string // FROM ... JOIN ...
+	where       []string
+	args        []interface{}
+	sortable    map[string]sortColumn
+	defaultSort []models.SortField
+	key         sortColumn // kolom unik, mis. id
+}
+
+func (q *pageQuery) filter(cond string, v interface{}) {
+	q.args = append(q.args, v)
+	q.where = append(q.where, fmt.Sprintf(cond, len(q.args)))
+}
+
+func (q *pageQuery) whereClause(extra ...string) string {
+	conds := append(append([]string{}, q.where...), extra...)
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// queryPage runs q for page p. scan reads q.columns and then the cursor key
+// (a []string) from the current row.
+func queryPage[T any](q *pageQuery, p models.PageRequest, scan func(pgx.Rows, *[]string) (T, error)) (*models.PageResponse[T], error) {
+	query, args, sort, err := q.pageSQL(p)
+	if err != nil {
+		return nil, err
+	}
+	out := &models.PageResponse[T]{Data: []T{}}
+
+	if p.WithTotal {
+		var total int
+		if err := config.DB.QueryRow(context.Background(),
+			`SELECT COUNT(*)::int `+q.from+q.whereClause(), q.args...).Scan(&total); err != nil {
+			return nil, err
+		}
+		out.Total = &total
+	}
+
+	rows, err := config.DB.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lastKey []string
+	for rows.Next() {
+		var key []string
+		item, err := scan(rows, &key)
+		if err != nil {
+			return nil, err
+		}
+		if len(out.Data) == p.Limit {
+			// ada baris berikutnya -> halaman selanjutnya dimulai setelah lastKey
+			out.NextCursor = models.EncodeCursor(sort, lastKey)
+			break
+		}
+		out.Data = append(out.Data, item)
+		lastKey = key
+	}
+	return out, rows.Err()
+}
+
+// pageSQL builds the SELECT of page p (limit+1 rows, the extra row tells
+// whether there is a next page) and returns the sort order the cursor uses.
+func (q *pageQuery) pageSQL(p models.PageRequest) (string, []interface{}, []models.SortField, error) {
+	sort := p.Sort
+	if len(sort) == 0 {
+		sort = q.defaultSort
+	}
+	cols := make([]sortColumn, 0, len(sort)+1)
+	desc := make([]bool, 0, len(sort)+1)
+	for _, f := range sort {
+		col, ok := q.sortable[f.Field]
+		if !ok {
+			return "", nil, nil, &models.PageError{Message: fmt.Sprintf("cannot sort by %q", f.Field)}
+		}
+		cols = append(cols, col)
+		desc = append(desc, f.Desc)
+	}
+	cols = append(cols, q.key)
+	desc = append(desc, false)
+
+	args := append([]interface{}{}, q.args...)
+
+	// (a, b, id) setelah cursor, dengan arah urutan per kolom:
+	// a > va OR (a = va AND b < vb) OR (a = va AND b = vb AND id > vid)
+	var extra []string
+	if p.Cursor != "" {
+		keys, err := models.DecodeCursor(p.Cursor, sort, len(cols))
+		if err != nil {
+			return "", nil, nil, err
+		}
+		for i, col := range cols {
+			if !col.validKey(keys[i]) {
+				return "", nil, nil, &models.PageError{Message: "invalid cursor"}
+			}
+		}
+		var ors []string
+		for i := range cols {
+			var ands []string
+			for j := 0; j <= i; j++ {
+				args = append(args, keys[j])
+				op := "="
+				if j == i {
+					op = ">"
+					if desc[j] {
+						op = "<"
+					}
+				}
+				ands = append(ands, fmt.Sprintf("%s %s $%d::text::%s", cols[j].expr, op, len(args), cols[j].typ))
+			}
+			ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+		}
+		extra = append(extra, "("+strings.Join(ors, " OR ")+")")
+	}
+
+	keyExprs := make([]string, len(cols))
+	orders := make([]string, len(cols))
+	for i, col := range cols {
+		keyExprs[i] = col.expr + "::text"
+		dir := "ASC"
+		if desc[i] {
+			dir = "DESC"
+		}
+		orders[i] = col.expr + " " + dir
+	}
+	args = append(args, p.Limit+1)
+	query := `SELECT ` + q.columns + `, ARRAY[` + strings.Join(keyExprs, ", ") + `] ` + q.from +
+		q.whereClause(extra...) + ` ORDER BY ` + strings.Join(orders, ", ") +
+		fmt.Sprintf(` LIMIT $%d`, len(args))
+	return query, args, sort, nil
+}
+
+// keyedRow lets an existing single-row scan helper (e.g. scanAchievement) be
+// used with queryPage by appending the cursor key destination.
+type keyedRow struct {
+	pgx.Row
+	key *[]string
+}
+
+func (r keyedRow) Scan(dest ...interface{}) error {
+	return r.Row.Scan(append(dest, r.key)...)
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Lutfania/ekrp/app/models"
+)
+
+func testPageQuery() *pageQuery {
+	q := &pageQuery{
+		columns: "id, name",
+		from:    "FROM things t",
+		sortable: map[string]sortColumn{
+			"created_at": {"t.created_at", "timestamptz"},
+			"name":       {"t.name", "text"},
+		},
+		defaultSort: []models.SortField{{Field: "created_at", Desc: true}},
+		key:         sortColumn{"t.id", "uuid"},
+	}
+	q.filter("t.status = $%d", "active")
+	return q
+}
+
+func TestPageSQLFirstPage(t *testing.T) {
+	query, args, sort, err := testPageQuery().pageSQL(models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT id, name, ARRAY[t.created_at::text, t.id::text] FROM things t" +
+		" WHERE t.status = $1 ORDER BY t.created_at DESC, t.id ASC LIMIT $2"
+	if query != want {
+		t.Fatalf("query =\n%s\nwant\n%s", query, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", 11}) {
+		t.Fatalf("args = %v", args)
+	}
+	if spec := models.SortSpec(sort); spec != "-created_at" {
+		t.Fatalf("sort = %q", spec)
+	}
+}
+
+// Kolom terakhir selalu kunci unik, dan tiap kolom memakai arah urutannya
+// sendiri, jadi nilai yang sama (tie) tetap terurut stabil lintas halaman.
+func TestPageSQLCursorTieBreak(t *testing.T) {
+	sort := []models.SortField{{Field: "name"}, {Field: "created_at", Desc: true}}
+	cursor := models.EncodeCursor(sort, []string{"budi", "2025-01-01 00:00:00+00", "8d0c1f2e-5b7a-4a49-9f0e-3c2d1b4a5e6f"})
+	query, args, _, err := testPageQuery().pageSQL(models.PageRequest{Limit: 5, Sort: sort, Cursor: cursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantWhere := " WHERE t.status = $1 AND (" +
+		"(t.name > $2::text::text) OR " +
+		"(t.name = $3::text::text AND t.created_at < $4::text::timestamptz) OR " +
+		"(t.name = $5::text::text AND t.created_at = $6::text::timestamptz AND t.id > $7::text::uuid))"
+	if !strings.Contains(query, wantWhere) {
+		t.Fatalf("query %s\ndoes not contain%s", query, wantWhere)
+	}
+	if !strings.HasSuffix(query, " ORDER BY t.name ASC, t.created_at DESC, t.id ASC LIMIT $8") {
+		t.Fatalf("order: %s", query)
+	}
+	wantArgs := []interface{}{"active", "budi", "budi", "2025-01-01 00:00:00+00",
+		"budi", "2025-01-01 00:00:00+00", "8d0c1f2e-5b7a-4a49-9f0e-3c2d1b4a5e6f", 6}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Fatalf("args = %v\nwant %v", args, wantArgs)
+	}
+}
+
+func TestPageSQLRejects(t *testing.T) {
+	q := testPageQuery()
+	var pe *models.PageError
+	if _, _, _, err := q.pageSQL(models.PageRequest{Limit: 5, Sort: []models.SortField{{Field: "password"}}}); !errors.As(err, &pe) {
+		t.Fatalf("unknown sort field: err = %v", err)
+	}
+	// cursor dari urutan lain tidak boleh dipakai
+	cursor := models.EncodeCursor([]models.SortField{{Field: "name"}}, []string{"a", "b"})
+	if _, _, _, err := q.pageSQL(models.PageRequest{Limit: 5, Cursor: cursor}); !errors.As(err, &pe) {
+		t.Fatalf("foreign cursor: err = %v", err)
+	}
+}
+
+// Nilai cursor yang tidak bisa di-cast ke tipe kolomnya ditolak sebelum query.
+func TestPageSQLCursorKeyTypes(t *testing.T) {
+	const id = "8d0c1f2e-5b7a-4a49-9f0e-3c2d1b4a5e6f"
+	tests := []struct {
+		keys []string
+		ok   bool
+	}{
+		{[]string{"2025-01-01 00:00:00+00", id}, true},
+		{[]string{"2025-01-01 07:30:15.123456+07", id}, true},
+		{[]string{"2025-01-01 07:30:15+05:30", id}, true},
+		{[]string{"2025-13-01 00:00:00+00", id}, false},
+		{[]string{"yesterday", id}, false},
+		{[]string{"2025-01-01 00:00:00+00", "id-9"}, false},
+		{[]string{"2025-01-01 00:00:00+00", id + "' OR 1=1"}, false},
+	}
+	for _, tt := range tests {
+		cursor := models.EncodeCursor([]models.SortField{{Field: "created_at", Desc: true}}, tt.keys)
+		_, _, _, err := testPageQuery().pageSQL(models.PageRequest{Limit: 5, Cursor: cursor})
+		var pe *models.PageError
+		if tt.ok && err != nil {
+			t.Errorf("%v: unexpected error %v", tt.keys, err)
+		}
+		if !tt.ok && (!errors.As(err, &pe) || pe.Message != "invalid cursor") {
+			t.Errorf("%v: err = %v, want invalid cursor", tt.keys, err)
+		}
+	}
+
+	q := testPageQuery()
+	q.sortable["points"] = sortColumn{"t.points", "int"}
+	q.sortable["name"] = sortColumn{"t.name", "text"}
+	for keys, ok := range map[[3]string]bool{
+		{"12", "budi", id}:       true,
+		{"1.5", "budi", id}:      false,
+		{"12", "bu\x00di", id}:   false,
+		{"99999999999", "x", id}: false,
+	} {
+		sort := []models.SortField{{Field: "points"}, {Field: "name"}}
+		cursor := models.EncodeCursor(sort, keys[:])
+		_, _, _, err := q.pageSQL(models.PageRequest{Limit: 5, Sort: sort, Cursor: cursor})
+		if (err == nil) != ok {
+			t.Errorf("%q: err = %v, want ok %v", keys, err, ok)
+		}
+	}
+}
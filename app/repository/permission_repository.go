@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+
+	"github.com/Lutfania/ekrp/app/models"
 	"github.com/Lutfania/ekrp/config"
 )
 
@@ -28,9 +30,128 @@ func (r *PermissionRepository) GetPermissionsByRole(roleID string) ([]string, er
 	perms := []string{}
 	for rows.Next() {
 		var name string
-		rows.Scan(&name)
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
 		perms = append(perms, name)
 	}
 
-	return perms, nil
+	return perms, rows.Err()
+}
+
+// ListPermissionNames returns every permission name in the permissions table.
+func (r *PermissionRepository) ListPermissionNames() ([]string, error) {
+	rows, err := config.DB.Query(context.Background(), `SELECT name FROM permissions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (r *PermissionRepository) ListPermissions() ([]models.Permission, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id, name, description FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Permission{}
+	for rows.Next() {
+		var p models.Permission
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ListByRole returns the full permission rows attached to a role.
+func (r *PermissionRepository) ListByRole(roleID string) ([]models.Permission, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT p.id, p.name, p.description
+		 FROM role_permissions rp
+		 JOIN permissions p ON p.id = rp.permission_id
+		 WHERE rp.role_id = $1
+		 ORDER BY p.name`, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Permission{}
+	for rows.Next() {
+		var p models.Permission
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *PermissionRepository) FindPermissionByID(id string) (*models.Permission, error) {
+	p := &models.Permission{}
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT id, name, description FROM permissions WHERE id = $1`, id).
+		Scan(&p.ID, &p.Name, &p.Description)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// AttachToRole returns false if the permission was already attached.
+func (r *PermissionRepository) AttachToRole(roleID, permissionID string, audit models.AuditEntry) (bool, error) {
+	return changeRolePermission(roleID, audit,
+		`INSERT INTO role_permissions (role_id, permission_id) VALUES ($1, $2)
+		 ON CONFLICT DO NOTHING`, roleID, permissionID)
+}
+
+// DetachFromRole returns false if the permission was not attached.
+func (r *PermissionRepository) DetachFromRole(roleID, permissionID string, audit models.AuditEntry) (bool, error) {
+	return changeRolePermission(roleID, audit,
+		`DELETE FROM role_permissions WHERE role_id = $1 AND permission_id = $2`, roleID, permissionID)
+}
+
+// changeRolePermission runs the statement and, if it changed a row, writes
+// the audit entry and revokes the tokens of the role's holders in the same
+// transaction.
+func changeRolePermission(roleID string, audit models.AuditEntry, sql string, args ...interface{}) (bool, error) {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() != 1 {
+		return false, nil
+	}
+	if err := insertAuditTx(ctx, tx, audit); err != nil {
+		return false, err
+	}
+	if err := revokeRoleTx(ctx, tx, roleID); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	expireRevocationCache()
+	return true, nil
 }
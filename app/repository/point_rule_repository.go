@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+)
+
+type PointRuleRepository struct{}
+
+func NewPointRuleRepository() *PointRuleRepository {
+	return &PointRuleRepository{}
+}
+
+// CreateRuleSet stores rules as the next version for the academic year.
+func (r *PointRuleRepository) CreateRuleSet(year string, rules []models.PointRule, createdBy string) (*models.PointRuleSet, error) {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// simpan bersamaan akan menghitung MAX(version) yang sama; kunci tabel
+	// (mode ini saling menunggu sesamanya, pembaca tidak terhalang)
+	if _, err := tx.Exec(ctx, `LOCK TABLE point_rule_sets IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, err
+	}
+
+	set := &models.PointRuleSet{AcademicYear: year}
+	err = tx.QueryRow(ctx,
+		`INSERT INTO point_rule_sets (academic_year, version, created_by)
+		 VALUES ($1, (SELECT COALESCE(MAX(version), 0) + 1 FROM point_rule_sets WHERE academic_year = $1), NULLIF($2, '')::uuid)
+		 RETURNING id, version, created_by, created_at`, year, createdBy).
+		Scan(&set.ID, &set.Version, &set.CreatedBy, &set.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		if err := tx.QueryRow(ctx,
+			`INSERT INTO point_rules (rule_set_id, achievement_type, level, rank, points)
+			 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			set.ID, rule.AchievementType, rule.Level, rule.Rank, rule.Points).Scan(&rule.ID); err != nil {
+			return nil, err
+		}
+		set.Rules = append(set.Rules, rule)
+	}
+	return set, tx.Commit(ctx)
+}
+
+// FindActive returns the newest rule set of the academic year, with rules.
+func (r *PointRuleRepository) FindActive(year string) (*models.PointRuleSet, error) {
+	set := &models.PointRuleSet{}
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT id, academic_year, version, created_by, created_at FROM point_rule_sets
+		 WHERE academic_year = $1 ORDER BY version DESC LIMIT 1`, year).
+		Scan(&set.ID, &set.AcademicYear, &set.Version, &set.CreatedBy, &set.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return set, r.loadRules(set)
+}
+
+func (r *PointRuleRepository) FindByID(id string) (*models.PointRuleSet, error) {
+	set := &models.PointRuleSet{}
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT id, academic_year, version, created_by, created_at FROM point_rule_sets WHERE id = $1`, id).
+		Scan(&set.ID, &set.AcademicYear, &set.Version, &set.CreatedBy, &set.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return set, r.loadRules(set)
+}
+
+// FindAll lists every rule set version (without rules).
+func (r *PointRuleRepository) FindAll() ([]models.PointRuleSet, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id, academic_year, version, created_by, created_at FROM point_rule_sets
+		 ORDER BY academic_year DESC, version DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.PointRuleSet{}
+	for rows.Next() {
+		var set models.PointRuleSet
+		if err := rows.Scan(&set.ID, &set.AcademicYear, &set.Version, &set.CreatedBy, &set.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, set)
+	}
+	return out, rows.Err()
+}
+
+func (r *PointRuleRepository) loadRules(set *models.PointRuleSet) error {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id, achievement_type, level, rank, points FROM point_rules
+		 WHERE rule_set_id = $1 ORDER BY achievement_type, level, rank`, set.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	set.Rules = []models.PointRule{}
+	for rows.Next() {
+		var rule models.PointRule
+		if err := rows.Scan(&rule.ID, &rule.AchievementType, &rule.Level, &rule.Rank, &rule.Points); err != nil {
+			return err
+		}
+		set.Rules = append(set.Rules, rule)
+	}
+	return rows.Err()
+}
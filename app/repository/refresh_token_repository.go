@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrRefreshTokenReused is returned by Rotate when the token was already
+// consumed (or revoked) by the time we tried to claim it.
+var ErrRefreshTokenReused = errors.New("refresh token already used")
+
+type RefreshTokenRepository struct{}
+
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{}
+}
+
+// Create inserts the first token of a new family (i.e. a fresh login).
+func (r *RefreshTokenRepository) Create(userID, tokenHash string, expiresAt time.Time) error {
+	_, err := config.DB.Exec(context.Background(),
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		 VALUES ($1, gen_random_uuid(), $2, $3)`,
+		userID, tokenHash, expiresAt)
+	return err
+}
+
+func (r *RefreshTokenRepository) FindByHash(tokenHash string) (*models.RefreshToken, error) {
+	row := config.DB.QueryRow(context.Background(),
+		`SELECT id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, replaced_by, created_at
+		 FROM refresh_tokens WHERE token_hash = $1`, tokenHash)
+	t := &models.RefreshToken{}
+	if err := row.Scan(&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt,
+		&t.UsedAt, &t.RevokedAt, &t.ReplacedBy, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Rotate marks the old token as used and inserts its replacement in the same
+// family, atomically. If another request consumed the token first it returns
+// ErrRefreshTokenReused.
+func (r *RefreshTokenRepository) Rotate(old *models.RefreshToken, newHash string, expiresAt time.Time) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var newID string
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		 VALUES ($1, $2, $3, $4) RETURNING id`,
+		old.UserID, old.FamilyID, newHash, expiresAt).Scan(&newID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET used_at = now(), replaced_by = $1
+		 WHERE id = $2 AND used_at IS NULL AND revoked_at IS NULL`,
+		newID, old.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() != 1 {
+		return ErrRefreshTokenReused
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeFamily revokes every token descended from the same login.
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`, familyID)
+	return err
+}
+
+const revokeAllForUserSQL = `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+
+// RevokeAllForUser revokes every outstanding refresh token of a user.
+func (r *RefreshTokenRepository) RevokeAllForUser(userID string) error {
+	_, err := config.DB.Exec(context.Background(), revokeAllForUserSQL, userID)
+	return err
+}
+
+// revokeAllForUserTx is RevokeAllForUser in the caller's transaction.
+func revokeAllForUserTx(ctx context.Context, tx pgx.Tx, userID string) error {
+	_, err := tx.Exec(ctx, revokeAllForUserSQL, userID)
+	return err
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrUploadOffsetMismatch is returned by Advance when the stored offset is not
+// the one the chunk was written at.
+var ErrUploadOffsetMismatch = errors.New("upload offset mismatch")
+
+type ResumableUploadRepository struct{}
+
+func NewResumableUploadRepository() *ResumableUploadRepository {
+	return &ResumableUploadRepository{}
+}
+
+const resumableUploadColumns = `id::text, achievement_id::text, user_id::text, file_name, upload_length, upload_offset, expires_at, created_at`
+
+func scanResumableUpload(row pgx.Row) (*models.ResumableUpload, error) {
+	u := &models.ResumableUpload{}
+	if err := row.Scan(&u.ID, &u.AchievementID, &u.UserID, &u.FileName, &u.Length, &u.Offset, &u.ExpiresAt, &u.CreatedAt); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (r *ResumableUploadRepository) Create(achievementID, userID, fileName string, length int64, expiresAt time.Time) (*models.ResumableUpload, error) {
+	return scanResumableUpload(config.DB.QueryRow(context.Background(),
+		`INSERT INTO resumable_uploads (achievement_id, user_id, file_name, upload_length, expires_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING `+resumableUploadColumns,
+		achievementID, userID, fileName, length, expiresAt))
+}
+
+// Find returns the upload (pgx.ErrNoRows if unknown).
+func (r *ResumableUploadRepository) Find(id string) (*models.ResumableUpload, error) {
+	return scanResumableUpload(config.DB.QueryRow(context.Background(),
+		`SELECT `+resumableUploadColumns+` FROM resumable_uploads WHERE id = $1`, id))
+}
+
+// Advance moves the offset from "from" to "to" and extends the expiry.
+func (r *ResumableUploadRepository) Advance(id string, from, to int64, expiresAt time.Time) error {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE resumable_uploads SET upload_offset = $3, expires_at = $4
+		 WHERE id = $1 AND upload_offset = $2`, id, from, to, expiresAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUploadOffsetMismatch
+	}
+	return nil
+}
+
+func (r *ResumableUploadRepository) Delete(id string) error {
+	_, err := config.DB.Exec(context.Background(), `DELETE FROM resumable_uploads WHERE id = $1`, id)
+	return err
+}
+
+// DeleteExpired removes uploads that expired before now and returns their IDs.
+func (r *ResumableUploadRepository) DeleteExpired(now time.Time) ([]string, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`DELETE FROM resumable_uploads WHERE expires_at < $1 RETURNING id::text`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+)
+
+type RoleRepository struct{}
+
+func NewRoleRepository() *RoleRepository {
+	return &RoleRepository{}
+}
+
+func (r *RoleRepository) FindAll() ([]models.Role, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id, name, description, created_at FROM roles ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, role)
+	}
+	return out, rows.Err()
+}
+
+func (r *RoleRepository) FindById(id string) (*models.Role, error) {
+	role := &models.Role{}
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT id, name, description, created_at FROM roles WHERE id = $1`, id).
+		Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (r *RoleRepository) FindNameById(id string) (string, error) {
+	var name string
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT name FROM roles WHERE id = $1`, id).Scan(&name)
+	return name, err
+}
+
+// Create inserts the role and its audit entry (EntityID diisi id role baru)
+// in one transaction.
+func (r *RoleRepository) Create(req *models.RoleRequest, audit models.AuditEntry) (*models.Role, error) {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	role := &models.Role{}
+	err = tx.QueryRow(ctx,
+		`INSERT INTO roles (name, description) VALUES ($1, $2)
+		 RETURNING id, name, description, created_at`, req.Name, req.Description).
+		Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	audit.EntityID = role.ID
+	if err := insertAuditTx(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (r *RoleRepository) Update(id string, req *models.RoleRequest, audit models.AuditEntry) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE roles SET name = $1, description = $2 WHERE id = $3`, req.Name, req.Description, id); err != nil {
+		return err
+	}
+	if err := insertAuditTx(ctx, tx, audit); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete removes the role together with its role_permissions rows.
+func (r *RoleRepository) Delete(id string, audit models.AuditEntry) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM roles WHERE id = $1`, id); err != nil {
+		return err
+	}
+	if err := insertAuditTx(ctx, tx, audit); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *RoleRepository) CountUsers(id string) (int, error) {
+	var n int
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT count(*) FROM users WHERE role_id = $1`, id).Scan(&n)
+	return n, err
+}
+
+func (r *RoleRepository) FindUsers(id string) ([]models.User, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id, username, email, full_name, role_id, is_active
+		 FROM users WHERE role_id = $1 ORDER BY username`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.User{}
+	for rows.Next() {
+		u := models.User{}
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.FullName, &u.RoleID, &u.IsActive); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
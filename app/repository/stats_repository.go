@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/Lutfania/ekrp/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// statsTimezone: periode dihitung dalam UTC di Postgres dan Mongo, sama
+// dengan batas ?from=&to= (tanggal UTC), jadi kedua timeline selalu cocok.
+const statsTimezone = "UTC"
+
+// levelChunkSize membatasi jumlah id per $in di LevelTimeline.
+const levelChunkSize = 1000
+
+// Format periode per interval, untuk Postgres (to_char) dan Mongo ($dateToString).
+var (
+	pgPeriodFormat = map[string]string{
+		"day":   `'YYYY-MM-DD'`,
+		"week":  `'IYYY-"W"IW'`,
+		"month": `'YYYY-MM'`,
+	}
+	mongoPeriodFormat = map[string]string{
+		"day":   "%Y-%m-%d",
+		"week":  "%G-W%V",
+		"month": "%Y-%m",
+	}
+)
+
+// StatsRepository menghitung agregat dashboard: angka per status/tipe dari
+// Postgres, tingkat (extra.level) lewat aggregation pipeline di Mongo.
+type StatsRepository struct{}
+
+func NewStatsRepository() *StatsRepository {
+	return &StatsRepository{}
+}
+
+// statsScope returns the FROM/WHERE part shared by every Postgres aggregate;
+// joins are appended to the FROM clause. Prestasi yang dihapus (soft delete)
+// tidak dihitung.
+func statsScope(f models.StatsFilter, joins ...string) (string, []interface{}) {
+	q := &pageQuery{
+		from:  ` FROM achievement_references ar JOIN students s ON s.id = ar.student_id` + strings.Join(joins, ""),
+		where: []string{"ar.status <> 'deleted'"},
+	}
+	if f.StudentID != "" {
+		q.filter("ar.student_id = $%d", f.StudentID)
+	}
+	if f.AdvisorID != "" {
+		q.filter("s.advisor_id = $%d", f.AdvisorID)
+	}
+	if f.From != nil {
+		q.filter("ar.created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		q.filter("ar.created_at < $%d", *f.To)
+	}
+	return q.from + q.whereClause(), q.args
+}
+
+func (r *StatsRepository) countBy(expr string, f models.StatsFilter) (map[string]int, error) {
+	scope, args := statsScope(f)
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT `+expr+`, COUNT(*)::int`+scope+` GROUP BY 1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var key string
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, err
+		}
+		out[key] = n
+	}
+	return out, rows.Err()
+}
+
+func (r *StatsRepository) CountByStatus(f models.StatsFilter) (map[string]int, error) {
+	return r.countBy("ar.status", f)
+}
+
+func (r *StatsRepository) CountByType(f models.StatsFilter) (map[string]int, error) {
+	return r.countBy("COALESCE(ar.achievement_type, '')", f)
+}
+
+// Timeline counts achievements created per period, broken down by "status" or "type".
+func (r *StatsRepository) Timeline(f models.StatsFilter, interval, by string) ([]models.StatsBucket, error) {
+	format, ok := pgPeriodFormat[interval]
+	if !ok {
+		return nil, fmt.Errorf("unknown interval %q", interval)
+	}
+	key := "ar.status"
+	if by == "type" {
+		key = "COALESCE(ar.achievement_type, '')"
+	}
+	scope, args := statsScope(f)
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT to_char(ar.created_at AT TIME ZONE '`+statsTimezone+`', `+format+`) AS period, `+key+`, COUNT(*)::int`+scope+`
+		 GROUP BY 1, 2 ORDER BY 1, 2`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.StatsBucket{}
+	for rows.Next() {
+		var b models.StatsBucket
+		if err := rows.Scan(&b.Period, &b.Key, &b.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *StatsRepository) ByProgramStudy(f models.StatsFilter) ([]models.ProgramStudyStats, error) {
+	scope, args := statsScope(f)
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT s.program_study, COUNT(DISTINCT s.id)::int, COUNT(*)::int,
+		        COUNT(*) FILTER (WHERE ar.status IN ('verified', 'archived'))::int`+scope+`
+		 GROUP BY s.program_study ORDER BY s.program_study`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.ProgramStudyStats{}
+	for rows.Next() {
+		var p models.ProgramStudyStats
+		if err := rows.Scan(&p.ProgramStudy, &p.Students, &p.Total, &p.Verified); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *StatsRepository) ByAdvisor(f models.StatsFilter) ([]models.AdvisorStats, error) {
+	scope, args := statsScope(f, ` JOIN lecturers l ON l.id = s.advisor_id JOIN users u ON u.id = l.user_id`)
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT l.id, u.full_name, COUNT(DISTINCT s.id)::int, COUNT(*)::int,
+		        COUNT(*) FILTER (WHERE ar.status = 'submitted')::int,
+		        COUNT(*) FILTER (WHERE ar.status IN ('verified', 'archived'))::int,
+		        COUNT(*) FILTER (WHERE ar.status = 'rejected')::int`+scope+`
+		 GROUP BY l.id, u.full_name ORDER BY u.full_name, l.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.AdvisorStats{}
+	for rows.Next() {
+		var a models.AdvisorStats
+		if err := rows.Scan(&a.AdvisorID, &a.AdvisorName, &a.Advisees, &a.Total, &a.Pending, &a.Verified, &a.Rejected); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// VerificationTime averages submitted_at -> verified_at over verified achievements.
+func (r *StatsRepository) VerificationTime(f models.StatsFilter) (*models.VerificationTimeStats, error) {
+	scope, args := statsScope(f)
+	var out models.VerificationTimeStats
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*)::int, AVG(EXTRACT(EPOCH FROM ar.verified_at - ar.submitted_at) / 3600)::float8`+scope+`
+		   AND ar.status IN ('verified', 'archived') AND ar.submitted_at IS NOT NULL AND ar.verified_at IS NOT NULL`,
+		args...).Scan(&out.Count, &out.AverageHours)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LevelTimeline menghitung dokumen per periode dan extra.level di Mongo untuk
+// prestasi dalam scope f (scope sama dengan Postgres). Id dibaca dari Postgres
+// dan dikirim ke Mongo per levelChunkSize, hasilnya dijumlahkan di sini.
+func (r *StatsRepository) LevelTimeline(f models.StatsFilter, interval string) ([]models.StatsBucket, error) {
+	format, ok := mongoPeriodFormat[interval]
+	if !ok {
+		return nil, fmt.Errorf("unknown interval %q", interval)
+	}
+	scope, args := statsScope(f)
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT ar.mongo_achievement_id`+scope+` AND ar.mongo_achievement_id IS NOT NULL`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[levelKey]int{}
+	chunk := make([]primitive.ObjectID, 0, levelChunkSize)
+	for rows.Next() {
+		var hex string
+		if err := rows.Scan(&hex); err != nil {
+			return nil, err
+		}
+		if oid, err := primitive.ObjectIDFromHex(hex); err == nil {
+			chunk = append(chunk, oid)
+		}
+		if len(chunk) == levelChunkSize {
+			if err := countLevels(chunk, format, counts); err != nil {
+				return nil, err
+			}
+			chunk = chunk[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(chunk) > 0 {
+		if err := countLevels(chunk, format, counts); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]models.StatsBucket, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, models.StatsBucket{Period: k.period, Key: k.level, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Period != out[j].Period {
+			return out[i].Period < out[j].Period
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
+
+type levelKey struct{ period, level string }
+
+// countLevels adds the period/level counts of the documents oids to counts.
+func countLevels(oids []primitive.ObjectID, format string, counts map[levelKey]int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"_id": bson.M{"$in": oids}}},
+		bson.M{"$group": bson.M{
+			"_id": bson.M{
+				"period": bson.M{"$dateToString": bson.M{"format": format, "date": "$created_at", "timezone": statsTimezone}},
+				"level":  bson.M{"$ifNull": bson.A{"$extra.level", ""}},
+			},
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+	cur, err := database.Collection("achievements").Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var row struct {
+			ID struct {
+				Period string      `bson:"period"`
+				Level  interface{} `bson:"level"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return err
+		}
+		level, _ := row.ID.Level.(string)
+		counts[levelKey{row.ID.Period, level}] += row.Count
+	}
+	return cur.Err()
+}
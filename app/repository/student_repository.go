@@ -5,49 +5,69 @@ import (
 	"database/sql"
 	"github.com/Lutfania/ekrp/app/models"
 	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
 )
 
 type StudentRepository struct{}
 
+// studentTotalPoints sums points of verified (incl. archived) achievements of s.id.
+const studentTotalPoints = `COALESCE((SELECT SUM(ar.points) FROM achievement_references ar
+		 WHERE ar.student_id = s.id AND ar.status IN ('verified', 'archived')), 0)`
+
 func NewStudentRepository() *StudentRepository {
 	return &StudentRepository{}
 }
 
-func (r *StudentRepository) FindAll() ([]models.Student, error) {
-	rows, err := config.DB.Query(context.Background(),
-		`SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at
-		 FROM students`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+// studentSort lists the fields FindPage can sort by.
+var studentSort = map[string]sortColumn{
+	"student_id":    {"s.student_id", "text"},
+	"program_study": {"s.program_study", "text"},
+	"academic_year": {"s.academic_year", "text"},
+	"created_at":    {"s.created_at", "timestamptz"},
+}
 
-	var out []models.Student
-	for rows.Next() {
+// FindPage returns one page of students matching f.
+func (r *StudentRepository) FindPage(f models.StudentFilter, p models.PageRequest) (*models.PageResponse[models.Student], error) {
+	q := &pageQuery{
+		columns: `s.id, s.user_id, s.student_id, s.program_study, s.academic_year, s.advisor_id, s.created_at,
+		        ` + studentTotalPoints + `, s.public_listing`,
+		from:        `FROM students s`,
+		sortable:    studentSort,
+		defaultSort: []models.SortField{{Field: "student_id"}},
+		key:         sortColumn{"s.id", "uuid"},
+	}
+	if f.ProgramStudy != "" {
+		q.filter("s.program_study = $%d", f.ProgramStudy)
+	}
+	if f.AcademicYear != "" {
+		q.filter("s.academic_year = $%d", f.AcademicYear)
+	}
+	if f.AdvisorID != "" {
+		q.filter("s.advisor_id = $%d", f.AdvisorID)
+	}
+	return queryPage(q, p, func(rows pgx.Rows, key *[]string) (models.Student, error) {
 		var s models.Student
 		var advisor sql.NullString
-		if err := rows.Scan(&s.ID, &s.UserID, &s.StudentID, &s.ProgramStudy, &s.AcademicYear, &advisor, &s.CreatedAt); err != nil {
-			return nil, err
+		if err := rows.Scan(&s.ID, &s.UserID, &s.StudentID, &s.ProgramStudy, &s.AcademicYear, &advisor, &s.CreatedAt, &s.TotalPoints, &s.PublicListing, key); err != nil {
+			return s, err
 		}
 		if advisor.Valid {
 			val := advisor.String
 			s.AdvisorID = &val
-		} else {
-			s.AdvisorID = nil
 		}
-		out = append(out, s)
-	}
-	return out, nil
+		return s, nil
+	})
 }
 
 func (r *StudentRepository) FindById(id string) (*models.Student, error) {
 	row := config.DB.QueryRow(context.Background(),
-		`SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at
-		 FROM students WHERE id = $1`, id)
+		`SELECT s.id, s.user_id, s.student_id, s.program_study, s.academic_year, s.advisor_id, s.created_at,
+		        `+studentTotalPoints+`, s.public_listing
+		 FROM students s WHERE s.id = $1`, id)
 
 	var s models.Student
 	var advisor sql.NullString
-	if err := row.Scan(&s.ID, &s.UserID, &s.StudentID, &s.ProgramStudy, &s.AcademicYear, &advisor, &s.CreatedAt); err != nil {
+	if err := row.Scan(&s.ID, &s.UserID, &s.StudentID, &s.ProgramStudy, &s.AcademicYear, &advisor, &s.CreatedAt, &s.TotalPoints, &s.PublicListing); err != nil {
 		return nil, err
 	}
 	if advisor.Valid {
@@ -59,6 +79,24 @@ func (r *StudentRepository) FindById(id string) (*models.Student, error) {
 	return &s, nil
 }
 
+// FindByUserID returns the student profile linked to a user account.
+func (r *StudentRepository) FindByUserID(userID string) (*models.Student, error) {
+	row := config.DB.QueryRow(context.Background(),
+		`SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at
+		 FROM students WHERE user_id = $1`, userID)
+
+	var s models.Student
+	var advisor sql.NullString
+	if err := row.Scan(&s.ID, &s.UserID, &s.StudentID, &s.ProgramStudy, &s.AcademicYear, &advisor, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	if advisor.Valid {
+		val := advisor.String
+		s.AdvisorID = &val
+	}
+	return &s, nil
+}
+
 func (r *StudentRepository) Create(req *models.CreateStudentRequest) error {
 	_, err := config.DB.Exec(context.Background(),
 		`INSERT INTO students (user_id, student_id, program_study, academic_year, advisor_id)
@@ -75,8 +113,8 @@ func (r *StudentRepository) UpdateAdvisor(id string, advisorID string) error {
 
 func (r *StudentRepository) FindAchievements(studentID string) ([]models.AchievementReference, error) {
 	rows, err := config.DB.Query(context.Background(),
-		`SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at
-		 FROM achievement_references WHERE student_id = $1`, studentID)
+		`SELECT `+achievementColumns+`
+		 FROM achievement_references ar WHERE ar.student_id = $1 AND ar.status <> 'deleted'`, studentID)
 	if err != nil {
 		return nil, err
 	}
@@ -84,40 +122,67 @@ func (r *StudentRepository) FindAchievements(studentID string) ([]models.Achieve
 
 	var out []models.AchievementReference
 	for rows.Next() {
-		var a models.AchievementReference
-		var submitted sql.NullTime
-		var verified sql.NullTime
-		var verifiedBy sql.NullString
-		var rejection sql.NullString
-		var updated sql.NullTime
-
-		if err := rows.Scan(
-			&a.ID, &a.StudentID, &a.MongoAchievementID, &a.Status,
-			&submitted, &verified, &verifiedBy, &rejection, &a.CreatedAt, &updated,
-		); err != nil {
+		a, err := scanAchievement(rows)
+		if err != nil {
 			return nil, err
 		}
-		if submitted.Valid {
-			tmp := submitted.Time
-			a.SubmittedAt = &tmp
-		}
-		if verified.Valid {
-			tmp := verified.Time
-			a.VerifiedAt = &tmp
-		}
-		if verifiedBy.Valid {
-			val := verifiedBy.String
-			a.VerifiedBy = &val
-		}
-		if rejection.Valid {
-			val := rejection.String
-			a.RejectionNote = &val
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+// PointsByType returns the total verified points of a student per achievement type.
+func (r *StudentRepository) PointsByType(studentID string) (map[string]int, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT COALESCE(achievement_type, ''), COALESCE(SUM(points), 0)
+		 FROM achievement_references
+		 WHERE student_id = $1 AND status IN ('verified', 'archived')
+		 GROUP BY achievement_type`, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]int{}
+	for rows.Next() {
+		var t string
+		var pts int
+		if err := rows.Scan(&t, &pts); err != nil {
+			return nil, err
 		}
-		if updated.Valid {
-			tmp := updated.Time
-			a.UpdatedAt = &tmp
+		out[t] = pts
+	}
+	return out, rows.Err()
+}
+
+// SetPublicListing sets whether the student appears on public leaderboards.
+func (r *StudentRepository) SetPublicListing(id string, public bool) error {
+	tag, err := config.DB.Exec(context.Background(),
+		`UPDATE students SET public_listing = $2 WHERE id = $1`, id, public)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// IDsByAdvisor returns the ids of the advisees of a lecturer.
+func (r *StudentRepository) IDsByAdvisor(lecturerID string) ([]string, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id FROM students WHERE advisor_id = $1`, lecturerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
 		}
-		out = append(out, a)
+		out = append(out, id)
 	}
-	return out, nil
+	return out, rows.Err()
 }
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
+)
+
+// revocationCache is shared by every TokenRevocationRepository so the middleware
+// and the services see the same state. It holds the full (small) set of
+// unexpired revocations and is reloaded from Postgres periodically so other
+// instances' revocations are picked up too.
+var revocationCache = struct {
+	sync.RWMutex
+	jtis     map[string]time.Time // jti -> token expiry
+	users    map[string]int       // user_id -> token_version
+	loadedAt time.Time
+}{}
+
+const revocationReloadEvery = 30 * time.Second
+
+type TokenRevocationRepository struct{}
+
+func NewTokenRevocationRepository() *TokenRevocationRepository {
+	return &TokenRevocationRepository{}
+}
+
+// RevokeToken revokes a single access token until its own expiry.
+func (r *TokenRevocationRepository) RevokeToken(jti, userID string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	_, err := config.DB.Exec(context.Background(),
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3)
+		 ON CONFLICT (jti) DO NOTHING`, jti, userID, expiresAt)
+	if err != nil {
+		return err
+	}
+	revocationCache.Lock()
+	if revocationCache.jtis != nil {
+		revocationCache.jtis[jti] = expiresAt
+	}
+	revocationCache.Unlock()
+	return nil
+}
+
+// RevokeUser invalidates every access token issued to the user so far by
+// bumping the user's token version. Token baru membawa versi yang baru
+// (lihat UserRepository.FindById), jadi refresh sesaat setelahnya tetap sah.
+func (r *TokenRevocationRepository) RevokeUser(userID string) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	version, err := revokeUserTx(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	cacheUserRevocation(userID, version)
+	return nil
+}
+
+// revokeUserTx bumps the user's token version in the caller's transaction
+// (panggil cacheUserRevocation dengan versi barunya setelah commit).
+func revokeUserTx(ctx context.Context, tx pgx.Tx, userID string) (int, error) {
+	var version int
+	err := tx.QueryRow(ctx,
+		`INSERT INTO user_token_revocations (user_id, token_version) VALUES ($1, 1)
+		 ON CONFLICT (user_id) DO UPDATE SET token_version = user_token_revocations.token_version + 1
+		 RETURNING token_version`, userID).Scan(&version)
+	return version, err
+}
+
+// cacheUserRevocation applies a committed revocation to this instance's cache
+// right away; instance lain melihatnya setelah reload.
+func cacheUserRevocation(userID string, version int) {
+	revocationCache.Lock()
+	if revocationCache.users != nil && version > revocationCache.users[userID] {
+		revocationCache.users[userID] = version
+	}
+	revocationCache.Unlock()
+}
+
+// revokeRoleTx invalidates access tokens of every user currently holding the
+// role, e.g. after its permissions change, in the caller's transaction (panggil
+// expireRevocationCache setelah commit). Refresh tokens are kept so clients
+// can simply refresh and receive the new permission set.
+func revokeRoleTx(ctx context.Context, tx pgx.Tx, roleID string) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO user_token_revocations (user_id, token_version)
+		 SELECT id, 1 FROM users WHERE role_id = $1
+		 ON CONFLICT (user_id) DO UPDATE SET token_version = user_token_revocations.token_version + 1`,
+		roleID)
+	return err
+}
+
+// expireRevocationCache forces a reload on the next check.
+func expireRevocationCache() {
+	revocationCache.Lock()
+	revocationCache.loadedAt = time.Time{}
+	revocationCache.Unlock()
+}
+
+// IsRevoked checks a token (its jti and tv claim) against the in-process
+// cache, reloading it when stale.
+func (r *TokenRevocationRepository) IsRevoked(jti, userID string, tokenVersion int) (bool, error) {
+	revocationCache.RLock()
+	stale := time.Since(revocationCache.loadedAt) > revocationReloadEvery
+	revocationCache.RUnlock()
+	if stale {
+		if err := r.reload(); err != nil {
+			return false, err
+		}
+	}
+
+	revocationCache.RLock()
+	defer revocationCache.RUnlock()
+	if jti != "" {
+		if _, ok := revocationCache.jtis[jti]; ok {
+			return true, nil
+		}
+	}
+	if tokenVersion < revocationCache.users[userID] {
+		return true, nil
+	}
+	return false, nil
+}
+
+// DeleteExpired removes revoked jtis whose token has expired anyway.
+func (r *TokenRevocationRepository) DeleteExpired() error {
+	_, err := config.DB.Exec(context.Background(), `DELETE FROM revoked_tokens WHERE expires_at < now()`)
+	return err
+}
+
+func (r *TokenRevocationRepository) reload() error {
+	ctx := context.Background()
+	jtis := map[string]time.Time{}
+	rows, err := config.DB.Query(ctx, `SELECT jti, expires_at FROM revoked_tokens WHERE expires_at >= now()`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var jti string
+		var exp time.Time
+		if err := rows.Scan(&jti, &exp); err != nil {
+			rows.Close()
+			return err
+		}
+		jtis[jti] = exp
+	}
+	rows.Close()
+
+	users := map[string]int{}
+	rows, err = config.DB.Query(ctx, `SELECT user_id, token_version FROM user_token_revocations`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID string
+		var version int
+		if err := rows.Scan(&userID, &version); err != nil {
+			return err
+		}
+		users[userID] = version
+	}
+
+	revocationCache.Lock()
+	revocationCache.jtis = jtis
+	revocationCache.users = users
+	revocationCache.loadedAt = time.Now()
+	revocationCache.Unlock()
+	return nil
+}
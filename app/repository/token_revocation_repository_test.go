@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsRevoked(t *testing.T) {
+	const user = "22222222-2222-2222-2222-222222222222"
+	revocationCache.Lock()
+	revocationCache.jtis = map[string]time.Time{"logged-out": time.Now().Add(time.Hour)}
+	revocationCache.users = map[string]int{user: 2}
+	revocationCache.loadedAt = time.Now() // tidak reload dari database
+	revocationCache.Unlock()
+	t.Cleanup(expireRevocationCache)
+
+	tests := []struct {
+		name    string
+		jti     string
+		userID  string
+		version int
+		want    bool
+	}{
+		{"token from before the last revocation", "a", user, 1, true},
+		{"token issued right after the revocation", "b", user, 2, false},
+		{"user without revocations", "c", "33333333-3333-3333-3333-333333333333", 0, false},
+		{"logged out jti", "logged-out", user, 2, true},
+		{"signed link without jti", "", user, 0, true},
+	}
+	r := NewTokenRevocationRepository()
+	for _, tt := range tests {
+		got, err := r.IsRevoked(tt.jti, tt.userID, tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: IsRevoked = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
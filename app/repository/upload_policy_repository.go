@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
+)
+
+type UploadPolicyRepository struct{}
+
+func NewUploadPolicyRepository() *UploadPolicyRepository {
+	return &UploadPolicyRepository{}
+}
+
+const uploadPolicyColumns = `achievement_type, max_size_bytes, allowed_types, updated_by::text, updated_at`
+
+func scanUploadPolicy(row pgx.Row) (*models.UploadPolicy, error) {
+	p := &models.UploadPolicy{}
+	if err := row.Scan(&p.AchievementType, &p.MaxSizeBytes, &p.AllowedTypes, &p.UpdatedBy, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *UploadPolicyRepository) FindAll() ([]models.UploadPolicy, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT `+uploadPolicyColumns+` FROM upload_policies ORDER BY achievement_type`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.UploadPolicy{}
+	for rows.Next() {
+		p, err := scanUploadPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+// Find returns the policy of achievementType (pgx.ErrNoRows if not configured).
+func (r *UploadPolicyRepository) Find(achievementType string) (*models.UploadPolicy, error) {
+	return scanUploadPolicy(config.DB.QueryRow(context.Background(),
+		`SELECT `+uploadPolicyColumns+` FROM upload_policies WHERE achievement_type = $1`, achievementType))
+}
+
+func (r *UploadPolicyRepository) Upsert(p *models.UploadPolicy, updatedBy string) (*models.UploadPolicy, error) {
+	return scanUploadPolicy(config.DB.QueryRow(context.Background(),
+		`INSERT INTO upload_policies (achievement_type, max_size_bytes, allowed_types, updated_by, updated_at)
+		 VALUES ($1, $2, $3, NULLIF($4, '')::uuid, now())
+		 ON CONFLICT (achievement_type) DO UPDATE
+		 SET max_size_bytes = EXCLUDED.max_size_bytes, allowed_types = EXCLUDED.allowed_types,
+		     updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+		 RETURNING `+uploadPolicyColumns,
+		p.AchievementType, p.MaxSizeBytes, p.AllowedTypes, updatedBy))
+}
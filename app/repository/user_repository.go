@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/Lutfania/ekrp/app/models"
 	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
 )
 
 type UserRepository struct{}
@@ -20,31 +21,55 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 	return err
 }
 
-func (r *UserRepository) FindAll() ([]models.User, error) {
-	rows, err := config.DB.Query(context.Background(),
-		`SELECT id, username, email, full_name, role_id, is_active FROM users`)
-	if err != nil {
-		return nil, err
-	}
-
-	var result []models.User
+// userSort lists the fields FindPage can sort by.
+var userSort = map[string]sortColumn{
+	"username":   {"u.username", "text"},
+	"email":      {"u.email", "text"},
+	"full_name":  {"u.full_name", "text"},
+	"created_at": {"u.created_at", "timestamptz"},
+}
 
-	for rows.Next() {
-		u := models.User{}
-		rows.Scan(&u.ID, &u.Username, &u.Email, &u.FullName, &u.RoleID, &u.IsActive)
-		result = append(result, u)
+// FindPage returns one page of users matching f.
+func (r *UserRepository) FindPage(f models.UserFilter, p models.PageRequest) (*models.PageResponse[models.User], error) {
+	q := &pageQuery{
+		columns:     `u.id, u.username, u.email, u.full_name, u.role_id, u.is_active, u.created_at`,
+		from:        `FROM users u`,
+		sortable:    userSort,
+		defaultSort: []models.SortField{{Field: "username"}},
+		key:         sortColumn{"u.id", "uuid"},
 	}
-
-	return result, nil
+	if f.RoleID != "" {
+		q.filter("u.role_id = $%d", f.RoleID)
+	}
+	if f.IsActive != nil {
+		q.filter("u.is_active = $%d", *f.IsActive)
+	}
+	if f.Search != "" {
+		q.filter("(u.username ILIKE $%[1]d OR u.email ILIKE $%[1]d OR u.full_name ILIKE $%[1]d)", "%"+f.Search+"%")
+	}
+	if f.From != nil {
+		q.filter("u.created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		q.filter("u.created_at < $%d", *f.To)
+	}
+	return queryPage(q, p, func(rows pgx.Rows, key *[]string) (models.User, error) {
+		u := models.User{}
+		err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.FullName, &u.RoleID, &u.IsActive, &u.CreatedAt, key)
+		return u, err
+	})
 }
 
+// FindById also reads the user's token version in the same statement, so a
+// token issued from it never pairs an old role with a new version.
 func (r *UserRepository) FindById(id string) (*models.User, error) {
 	row := config.DB.QueryRow(context.Background(),
-		`SELECT id, username, email, full_name, role_id, is_active 
-		 FROM users WHERE id = $1`, id)
+		`SELECT u.id, u.username, u.email, u.full_name, u.role_id, u.is_active, COALESCE(t.token_version, 0)
+		 FROM users u LEFT JOIN user_token_revocations t ON t.user_id = u.id
+		 WHERE u.id = $1`, id)
 
 	u := models.User{}
-	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.FullName, &u.RoleID, &u.IsActive)
+	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.FullName, &u.RoleID, &u.IsActive, &u.TokenVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -53,11 +78,12 @@ func (r *UserRepository) FindById(id string) (*models.User, error) {
 }
 func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 	row := config.DB.QueryRow(context.Background(),
-		`SELECT id, username, email, password_hash, full_name, role_id, is_active
-		 FROM users WHERE email=$1`, email)
+		`SELECT u.id, u.username, u.email, u.password_hash, u.full_name, u.role_id, u.is_active, COALESCE(t.token_version, 0)
+		 FROM users u LEFT JOIN user_token_revocations t ON t.user_id = u.id
+		 WHERE u.email=$1`, email)
 
 	u := models.User{}
-	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.FullName, &u.RoleID, &u.IsActive)
+	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.FullName, &u.RoleID, &u.IsActive, &u.TokenVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +113,57 @@ func (r *UserRepository) GetRolePermissions(roleID string) ([]string, error) {
 	return permissions, nil
 }
 
+// RecipientsWithPermission returns the active users whose role has permission
+// (mis. admin lewat achievement:manage), untuk notifikasi.
+func (r *UserRepository) RecipientsWithPermission(permission string) ([]*models.Recipient, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT u.id::text, u.email, u.full_name, u.language
+		 FROM users u
+		 JOIN role_permissions rp ON rp.role_id = u.role_id
+		 JOIN permissions p ON p.id = rp.permission_id
+		 WHERE p.name = $1 AND u.is_active
+		 ORDER BY u.full_name, u.id`, permission)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []*models.Recipient{}
+	for rows.Next() {
+		rc := &models.Recipient{}
+		if err := rows.Scan(&rc.UserID, &rc.Email, &rc.FullName, &rc.Language); err != nil {
+			return nil, err
+		}
+		out = append(out, rc)
+	}
+	return out, rows.Err()
+}
+
+// FindActorProfile reads the user, role, permission flag and student/lecturer
+// profile in one query. roleID kosong berarti role user saat ini. User yang
+// tidak ada tetap menghasilkan baris dengan UserFound=false.
+func (r *UserRepository) FindActorProfile(userID, roleID, permission string) (*models.ActorProfile, error) {
+	a := &models.ActorProfile{}
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT u.id IS NOT NULL, COALESCE(u.is_active, false),
+		        COALESCE(k.role_id::text, ''), COALESCE(ro.name, ''), COALESCE(u.full_name, ''),
+		        EXISTS (SELECT 1 FROM role_permissions rp
+		                JOIN permissions p ON p.id = rp.permission_id
+		                WHERE rp.role_id = k.role_id AND p.name = $3),
+		        COALESCE(s.id::text, ''), COALESCE(l.id::text, '')
+		 FROM (SELECT $1::uuid AS user_id) q
+		 LEFT JOIN users u ON u.id = q.user_id
+		 CROSS JOIN LATERAL (SELECT COALESCE(NULLIF($2::text, '')::uuid, u.role_id) AS role_id) k
+		 LEFT JOIN roles ro ON ro.id = k.role_id
+		 LEFT JOIN LATERAL (SELECT id FROM students WHERE user_id = q.user_id LIMIT 1) s ON true
+		 LEFT JOIN LATERAL (SELECT id FROM lecturers WHERE user_id = q.user_id LIMIT 1) l ON true`,
+		userID, roleID, permission).Scan(&a.UserFound, &a.IsActive, &a.RoleID, &a.RoleName,
+		&a.FullName, &a.HasPermission, &a.StudentID, &a.LecturerID)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
 func (r *UserRepository) UpdateUser(id string, req *models.UpdateUserRequest) error {
 	_, err := config.DB.Exec(context.Background(),
 		`UPDATE users SET username=$1, email=$2, full_name=$3 WHERE id=$4`,
@@ -100,8 +177,46 @@ func (r *UserRepository) DeleteUser(id string) error {
 	return err
 }
 
-func (r *UserRepository) UpdateUserRole(id, roleID string) error {
+// UpdateUserRole moves the user to roleID. Permission di token lama tidak
+// berlaku lagi, jadi semua access dan refresh token user dicabut dan audit
+// (details: old_role_id, new_role_id) ditulis dalam transaksi yang sama.
+// Returns pgx.ErrNoRows if the user does not exist.
+func (r *UserRepository) UpdateUserRole(id, roleID string, audit models.AuditEntry) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var oldRoleID string
+	if err := tx.QueryRow(ctx,
+		`SELECT role_id FROM users WHERE id = $1 FOR UPDATE`, id).Scan(&oldRoleID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET role_id=$1 WHERE id=$2`, roleID, id); err != nil {
+		return err
+	}
+	version, err := revokeUserTx(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if err := revokeAllForUserTx(ctx, tx, id); err != nil {
+		return err
+	}
+	audit.Details = map[string]interface{}{"old_role_id": oldRoleID, "new_role_id": roleID}
+	if err := insertAuditTx(ctx, tx, audit); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	cacheUserRevocation(id, version)
+	return nil
+}
+
+func (r *UserRepository) UpdateUserStatus(id string, isActive bool) error {
 	_, err := config.DB.Exec(context.Background(),
-		`UPDATE users SET role_id=$1 WHERE id=$2`, roleID, id)
+		`UPDATE users SET is_active=$1 WHERE id=$2`, isActive, id)
 	return err
 }
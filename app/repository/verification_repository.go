@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/jackc/pgx/v5"
+)
+
+type VerificationRepository struct{}
+
+func NewVerificationRepository() *VerificationRepository {
+	return &VerificationRepository{}
+}
+
+const verificationColumns = `code, achievement_id::text, issued_by::text, issued_at, revoked_at, revoked_by::text, revoke_reason`
+
+func scanVerificationCode(row pgx.Row) (*models.VerificationCode, error) {
+	v := &models.VerificationCode{}
+	if err := row.Scan(&v.Code, &v.AchievementID, &v.IssuedBy, &v.IssuedAt, &v.RevokedAt, &v.RevokedBy, &v.RevokeReason); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// insertVerificationCodeTx issues code for the achievement using the caller's
+// transaction (lihat AchievementRepository.TransitionStatus).
+func insertVerificationCodeTx(ctx context.Context, tx pgx.Tx, achievementID, code, issuedBy string) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO verification_codes (code, achievement_id, issued_by) VALUES ($1, $2, NULLIF($3, '')::uuid)`,
+		code, achievementID, issuedBy)
+	return err
+}
+
+// Reissue revokes the active code of the achievement (if any) and issues code.
+func (r *VerificationRepository) Reissue(achievementID, code, issuedBy, reason string) (*models.VerificationCode, error) {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, revokeQuery, achievementID, issuedBy, reason); err != nil {
+		return nil, err
+	}
+	if err := insertVerificationCodeTx(ctx, tx, achievementID, code, issuedBy); err != nil {
+		return nil, err
+	}
+	v, err := scanVerificationCode(tx.QueryRow(ctx,
+		`SELECT `+verificationColumns+` FROM verification_codes WHERE code = $1`, code))
+	if err != nil {
+		return nil, err
+	}
+	return v, tx.Commit(ctx)
+}
+
+// FindActive returns the active code of the achievement (pgx.ErrNoRows if none).
+func (r *VerificationRepository) FindActive(achievementID string) (*models.VerificationCode, error) {
+	return scanVerificationCode(config.DB.QueryRow(context.Background(),
+		`SELECT `+verificationColumns+` FROM verification_codes
+		 WHERE achievement_id = $1 AND revoked_at IS NULL`, achievementID))
+}
+
+// ListByAchievement returns every code of the achievement, newest first.
+func (r *VerificationRepository) ListByAchievement(achievementID string) ([]models.VerificationCode, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT `+verificationColumns+` FROM verification_codes
+		 WHERE achievement_id = $1 ORDER BY issued_at DESC`, achievementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.VerificationCode{}
+	for rows.Next() {
+		v, err := scanVerificationCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *v)
+	}
+	return out, rows.Err()
+}
+
+const revokeQuery = `UPDATE verification_codes
+	SET revoked_at = now(), revoked_by = NULLIF($2, '')::uuid, revoke_reason = NULLIF($3, '')
+	WHERE achievement_id = $1 AND revoked_at IS NULL`
+
+// Revoke revokes the active code of the achievement; false if there was none.
+func (r *VerificationRepository) Revoke(achievementID, revokedBy, reason string) (bool, error) {
+	tag, err := config.DB.Exec(context.Background(), revokeQuery, achievementID, revokedBy, reason)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// FindPublic resolves a code to the data shown on the public verification
+// page (pgx.ErrNoRows if the code does not exist).
+func (r *VerificationRepository) FindPublic(code string) (*models.PublicVerification, error) {
+	v := &models.PublicVerification{Code: code}
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT su.full_name, COALESCE(vu.full_name, ''), ar.verified_at, vc.revoked_at,
+		        ar.mongo_achievement_id, COALESCE(ar.achievement_type, ''), ar.status
+		 FROM verification_codes vc
+		 JOIN achievement_references ar ON ar.id = vc.achievement_id
+		 JOIN students s ON s.id = ar.student_id
+		 JOIN users su ON su.id = s.user_id
+		 LEFT JOIN users vu ON vu.id = ar.verified_by
+		 WHERE vc.code = $1`, code).
+		Scan(&v.StudentName, &v.VerifiedBy, &v.VerifiedAt, &v.RevokedAt,
+			&v.MongoAchievementID, &v.AchievementType, &v.AchievementStatus)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/Lutfania/ekrp/storage"
+	"github.com/Lutfania/ekrp/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// attachmentKey builds the storage key of a file: achievements/<id>/<file_id><ext>.
+func attachmentKey(achievementID, fileID, fileName string) string {
+	ext := strings.ToLower(path.Ext(fileName))
+	if len(ext) > 10 {
+		ext = ""
+	}
+	return fmt.Sprintf("achievements/%s/%s%s", achievementID, fileID, ext)
+}
+
+// storeAttachment streams r into the storage backend under fileID while
+// hashing it and returns the metadata to record in Mongo.
+func (s *AchievementService) storeAttachment(ar *models.AchievementReference, fileID, fileName, contentType string, size int64, r io.Reader, uploadedBy string) (*models.Attachment, error) {
+	key := attachmentKey(ar.ID, fileID, fileName)
+
+	hasher := sha256.New()
+	counter := &countingReader{r: io.TeeReader(r, hasher)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	if err := s.Files.Put(ctx, key, counter, size, contentType); err != nil {
+		return nil, err
+	}
+	meta := &models.Attachment{
+		FileID:      fileID,
+		FileName:    path.Base(fileName),
+		FileSize:    counter.n,
+		ContentType: contentType,
+		SHA256:      hex.EncodeToString(hasher.Sum(nil)),
+		Backend:     s.Files.Name(),
+		StorageKey:  key,
+		UploadedBy:  uploadedBy,
+		UploadedAt:  time.Now(),
+	}
+	if canPreview(contentType) {
+		meta.PreviewStatus = models.PreviewPending
+	}
+	return meta, nil
+}
+
+// attachFile stores the content and appends it to the achievement's files.
+func (s *AchievementService) attachFile(ar *models.AchievementReference, fileName, contentType string, size int64, r io.Reader, uploadedBy string) (*models.Attachment, error) {
+	meta, err := s.storeAttachment(ar, uuid.NewString(), fileName, contentType, size, r, uploadedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.MongoRepo.AddFile(ar.MongoAchievementID, *meta); err != nil {
+		// metadata gagal disimpan: hapus lagi isinya supaya tidak yatim
+		_ = s.Files.Delete(context.Background(), meta.StorageKey)
+		return nil, err
+	}
+	s.enqueuePreview(ar, *meta)
+	return meta, nil
+}
+
+func (s *AchievementService) enqueuePreview(ar *models.AchievementReference, f models.Attachment) {
+	if f.PreviewStatus == models.PreviewPending {
+		s.Previews.Enqueue(models.PreviewJob{MongoID: ar.MongoAchievementID, File: f})
+	}
+}
+
+// removeStored deletes the content (and preview) of a file that is no longer referenced.
+func (s *AchievementService) removeStored(f *models.Attachment) {
+	if f.StorageKey != "" {
+		_ = s.Files.Delete(context.Background(), f.StorageKey)
+	}
+	if f.PreviewKey != "" {
+		_ = s.Files.Delete(context.Background(), f.PreviewKey)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// UploadAttachment -> POST /api/v1/achievements/:id/attachments
+// multipart/form-data; field "file" (single)
+func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ar, _, err := s.Policy.Authorize(c, id, ActionModify)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := checkAttachmentsEditable(ar); err != nil {
+		return respondPolicyError(c, err)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "file required"})
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "cannot open file"})
+	}
+	defer f.Close()
+
+	contentType, err := s.Uploads.Validate(ar.AchievementType, fileHeader.Size, f)
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+
+	uploader, _ := c.Locals("user_id").(string)
+	meta, err := s.attachFile(ar, fileHeader.Filename, contentType, fileHeader.Size, f, uploader)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "cannot store file: " + err.Error()})
+	}
+	uploadsAccepted.Add(1)
+	return c.Status(201).JSON(fiber.Map{"message": "attachment uploaded", "file": meta})
+}
+
+// checkAttachmentsEditable: lampiran hanya boleh diubah selama konten bisa
+// diedit (draft/rejected), sama seperti judul dan deskripsi.
+func checkAttachmentsEditable(ar *models.AchievementReference) error {
+	if ar.MongoAchievementID == "" {
+		return &PolicyError{Status: 400, Message: "no mongo document linked"}
+	}
+	if !models.IsEditableStatus(ar.Status) {
+		return &PolicyError{Status: 409, Message: "attachments cannot be changed in status " + ar.Status}
+	}
+	return nil
+}
+
+// findAttachment loads the document of ar and the files entry with fileID.
+func (s *AchievementService) findAttachment(ar *models.AchievementReference, fileID string) (*models.MongoAchievement, *models.Attachment, error) {
+	if ar.MongoAchievementID == "" {
+		return nil, nil, &PolicyError{Status: 404, Message: "file not found"}
+	}
+	doc, err := s.MongoRepo.FindByIDHex(ar.MongoAchievementID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range doc.Files {
+		if doc.Files[i].FileID == fileID {
+			return doc, &doc.Files[i], nil
+		}
+	}
+	return nil, nil, &PolicyError{Status: 404, Message: "file not found"}
+}
+
+// downloadURL returns a signed, expiring URL for the file on behalf of viewer.
+func downloadURL(achievementID, fileID string, viewer *Actor) (string, time.Time) {
+	exp := time.Now().Add(utils.DownloadURLTTL())
+	return fmt.Sprintf("/api/v1/downloads/%s/%s?%s", achievementID, fileID,
+		utils.SignDownload(achievementID, fileID, viewer.UserID, viewer.TokenVersion, exp)), exp
+}
+
+// previewURL is downloadURL for the preview; the signature covers "<file_id>/preview".
+func previewURL(achievementID, fileID string, viewer *Actor) string {
+	return fmt.Sprintf("/api/v1/downloads/%s/%s/preview?%s", achievementID, fileID,
+		utils.SignDownload(achievementID, fileID+"/preview", viewer.UserID, viewer.TokenVersion, time.Now().Add(utils.DownloadURLTTL())))
+}
+
+// withPreviewURLs fills PreviewURL of the files whose preview is ready
+// (tanpa viewer, mis. untuk kredensial, tidak ada URL yang dibuat).
+func withPreviewURLs(achievementID string, files []models.Attachment, viewer *Actor) {
+	if viewer == nil {
+		return
+	}
+	for i := range files {
+		if files[i].PreviewStatus == models.PreviewReady && files[i].FileID != "" {
+			files[i].PreviewURL = previewURL(achievementID, files[i].FileID, viewer)
+		}
+	}
+}
+
+// ListAttachments -> GET /api/v1/achievements/:id/attachments
+// Setiap file disertai URL download bertanda tangan (tanpa bearer token).
+func (s *AchievementService) ListAttachments(c *fiber.Ctx) error {
+	ar, actor, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	out := []fiber.Map{}
+	if ar.MongoAchievementID != "" {
+		doc, err := s.MongoRepo.FindByIDHex(ar.MongoAchievementID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		withPreviewURLs(ar.ID, doc.Files, actor)
+		for _, f := range doc.Files {
+			if f.FileID == "" {
+				continue // metadata lama tanpa isi tersimpan
+			}
+			url, exp := downloadURL(ar.ID, f.FileID, actor)
+			out = append(out, fiber.Map{"file": f, "download_url": url, "expires_at": exp})
+		}
+	}
+	return c.JSON(out)
+}
+
+// DownloadAttachment -> GET /api/v1/achievements/:id/attachments/:fileId (bearer token)
+func (s *AchievementService) DownloadAttachment(c *fiber.Ctx) error {
+	ar, actor, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	return s.sendAttachment(c, ar, actor, c.Params("fileId"), "bearer", false)
+}
+
+// PreviewAttachment -> GET /api/v1/achievements/:id/attachments/:fileId/preview
+func (s *AchievementService) PreviewAttachment(c *fiber.Ctx) error {
+	ar, actor, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	return s.sendAttachment(c, ar, actor, c.Params("fileId"), "bearer", true)
+}
+
+// SignedDownload -> GET /api/v1/downloads/:id/:fileId?uid=&tv=&exp=&sig=
+// Tanpa JWT: tanda tangan HMAC dicek, lalu hak akses pemilik link dicek ulang.
+func (s *AchievementService) SignedDownload(c *fiber.Ctx) error {
+	return s.signedDownload(c, false)
+}
+
+// SignedPreview -> GET /api/v1/downloads/:id/:fileId/preview?uid=&tv=&exp=&sig=
+func (s *AchievementService) SignedPreview(c *fiber.Ctx) error {
+	return s.signedDownload(c, true)
+}
+
+func (s *AchievementService) signedDownload(c *fiber.Ctx, preview bool) error {
+	id, fileID, uid := c.Params("id"), c.Params("fileId"), c.Query("uid")
+	signed := fileID
+	if preview {
+		signed += "/preview"
+	}
+	tokenVersion, err := utils.VerifyDownload(id, signed, uid, c.Query("tv"), c.Query("exp"), c.Query("sig"))
+	if err != nil {
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	}
+	actor, err := s.Policy.ActorByUserID(uid, tokenVersion)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	// FindByID tidak mengembalikan prestasi berstatus deleted -> 404
+	ar, err := s.PGRepo.FindByID(id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(404).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.Policy.Check(actor, ar.StudentID, ActionView); err != nil {
+		return respondPolicyError(c, err)
+	}
+	return s.sendAttachment(c, ar, actor, fileID, "signed_url", preview)
+}
+
+// sendAttachment streams a file (or its preview) after the access check done
+// by the caller. Download file asli dicatat di audit log.
+func (s *AchievementService) sendAttachment(c *fiber.Ctx, ar *models.AchievementReference, actor *Actor, fileID, via string, preview bool) error {
+	_, file, err := s.findAttachment(ar, fileID)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if preview {
+		if file.PreviewStatus != models.PreviewReady || file.PreviewKey == "" {
+			return c.Status(404).JSON(fiber.Map{"error": "preview not available", "preview_status": file.PreviewStatus})
+		}
+		body, err := s.Files.Get(context.Background(), file.PreviewKey)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "preview not available"})
+		}
+		c.Set(fiber.HeaderContentType, "image/jpeg")
+		c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+		return c.SendStream(body)
+	}
+
+	body, err := s.Files.Get(context.Background(), file.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "file content missing"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := s.Audit.Log(actor.UserID, "attachment.download", "achievement", ar.ID, map[string]interface{}{
+		"file_id":   file.FileID,
+		"file_name": file.FileName,
+		"via":       via,
+	}, c.IP()); err != nil {
+		body.Close()
+		return c.Status(500).JSON(fiber.Map{"error": "cannot write audit log"})
+	}
+
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": file.FileName}))
+	c.Set("X-Content-SHA256", file.SHA256)
+	return c.SendStream(body, int(file.FileSize))
+}
+
+// ReplaceAttachment -> PUT /api/v1/achievements/:id/attachments/:fileId
+// multipart/form-data; field "file". Revisi baru mendapat file_id baru
+// (dikembalikan di response), entri lama diganti di tempat yang sama.
+func (s *AchievementService) ReplaceAttachment(c *fiber.Ctx) error {
+	ar, _, err := s.Policy.Authorize(c, c.Params("id"), ActionModify)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := checkAttachmentsEditable(ar); err != nil {
+		return respondPolicyError(c, err)
+	}
+	_, old, err := s.findAttachment(ar, c.Params("fileId"))
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "file required"})
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "cannot open file"})
+	}
+	defer f.Close()
+
+	contentType, err := s.Uploads.Validate(ar.AchievementType, fileHeader.Size, f)
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+
+	// setiap revisi disimpan dengan file_id dan key baru; isi lama baru
+	// dihapus setelah metadata berpindah ke revisi baru
+	uploader, _ := c.Locals("user_id").(string)
+	meta, err := s.storeAttachment(ar, uuid.NewString(), fileHeader.Filename, contentType, fileHeader.Size, f, uploader)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "cannot store file: " + err.Error()})
+	}
+	if err := s.MongoRepo.SwapFile(ar.MongoAchievementID, *old, *meta); err != nil {
+		_ = s.Files.Delete(context.Background(), meta.StorageKey)
+		if errors.Is(err, repository.ErrFileNotFound) {
+			return c.Status(409).JSON(fiber.Map{"error": "file was changed or removed, reload and retry"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	s.removeStored(old)
+	s.enqueuePreview(ar, *meta)
+	uploadsAccepted.Add(1)
+	return c.JSON(fiber.Map{"message": "attachment replaced", "file": meta})
+}
+
+// DeleteAttachment -> DELETE /api/v1/achievements/:id/attachments/:fileId
+func (s *AchievementService) DeleteAttachment(c *fiber.Ctx) error {
+	ar, _, err := s.Policy.Authorize(c, c.Params("id"), ActionModify)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := checkAttachmentsEditable(ar); err != nil {
+		return respondPolicyError(c, err)
+	}
+	_, file, err := s.findAttachment(ar, c.Params("fileId"))
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := s.MongoRepo.RemoveFile(ar.MongoAchievementID, file.FileID); err != nil {
+		if errors.Is(err, repository.ErrFileNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "file not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	s.removeStored(file)
+	return c.JSON(fiber.Map{"message": "attachment deleted"})
+}
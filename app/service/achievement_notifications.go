@@ -0,0 +1,188 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/mailer"
+	"github.com/Lutfania/ekrp/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// notificationText returns the title and body of an event notification.
+func notificationText(event, title, actorName, detail string) (string, string) {
+	switch event {
+	case models.EventAchievementSubmitted:
+		return "Prestasi menunggu verifikasi", fmt.Sprintf("%s mengajukan %q untuk diverifikasi.", actorName, title)
+	case models.EventAchievementVerified:
+		return "Prestasi terverifikasi", fmt.Sprintf("%q telah diverifikasi oleh %s.", title, actorName)
+	case models.EventAchievementRejected:
+		return "Prestasi ditolak", fmt.Sprintf("%q ditolak oleh %s. Catatan: %s", title, actorName, detail)
+	case models.EventAchievementCommented:
+		return "Komentar baru", fmt.Sprintf("%s mengomentari %q: %s", actorName, title, excerpt(detail, 140))
+	}
+	return event, title
+}
+
+func excerpt(s string, max int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= max {
+		return string(r)
+	}
+	return string(r[:max]) + "…"
+}
+
+// notify builds the notifications of event on ar: pengajuan ke dosen wali
+// (atau ke admin bila mahasiswa belum punya dosen wali), hasil verifikasi ke
+// mahasiswa, komentar ke keduanya. The actor never
+// notifies themselves. Bila email aktif, event di models.EmailEvents juga
+// membawa email yang ikut diantrekan di outbox. Gagal membaca penerima atau
+// merender email tidak menggagalkan aksi.
+func (s *AchievementService) notify(ar *models.AchievementReference, event string, actor *Actor, detail string) []models.NotificationDraft {
+	student, advisor, err := s.PGRepo.Participants(ar.ID)
+	if err != nil {
+		log.Println("notify:", ar.ID, err)
+		return nil
+	}
+	var recipients []*models.Recipient
+	switch event {
+	case models.EventAchievementSubmitted:
+		recipients = []*models.Recipient{advisor}
+		if advisor == nil {
+			// tanpa dosen wali, pengajuan hanya bisa diverifikasi admin
+			admins, err := s.Policy.UserRepo.RecipientsWithPermission(PermissionManageAchievements)
+			if err != nil {
+				log.Println("notify:", ar.ID, err)
+			}
+			recipients = admins
+		}
+	case models.EventAchievementVerified, models.EventAchievementRejected:
+		recipients = []*models.Recipient{student}
+	case models.EventAchievementCommented:
+		recipients = []*models.Recipient{student, advisor}
+	}
+
+	title := ""
+	if doc, err := s.MongoRepo.FindByIDHex(ar.MongoAchievementID); err == nil && doc != nil {
+		title = doc.Title
+	}
+	subject, body := notificationText(event, title, actor.FullName, detail)
+
+	drafts := []models.NotificationDraft{}
+	for _, r := range recipients {
+		if r == nil || r.UserID == actor.UserID {
+			continue
+		}
+		d := models.NotificationDraft{
+			UserID:        r.UserID,
+			Event:         event,
+			AchievementID: ar.ID,
+			ActorID:       actor.UserID,
+			ActorName:     actor.FullName,
+			Title:         subject,
+			Body:          body,
+		}
+		if s.Mailer != nil && r.Email != "" && models.IsEmailEvent(event) {
+			studentName := ""
+			if student != nil {
+				studentName = student.FullName
+			}
+			d.Email = renderEmail(event, r, mailer.TemplateData{
+				RecipientName:    r.FullName,
+				StudentName:      studentName,
+				ActorName:        actor.FullName,
+				AchievementTitle: title,
+				Note:             detail,
+				URL:              achievementURL(ar.ID),
+				Institution:      utils.InstitutionName(),
+			})
+		}
+		drafts = append(drafts, d)
+	}
+	if len(drafts) == 0 && event == models.EventAchievementSubmitted {
+		log.Println("notify:", ar.ID, "submitted but nobody was notified (no advisor or admin)")
+	}
+	return drafts
+}
+
+// notifier defers notify until TransitionStatus has accepted the change
+// (lihat models.StatusChange.Notify).
+func (s *AchievementService) notifier(ar *models.AchievementReference, event string, actor *Actor, detail string) func() []models.NotificationDraft {
+	return func() []models.NotificationDraft { return s.notify(ar, event, actor, detail) }
+}
+
+// renderEmail renders the email of event for r in r's language.
+func renderEmail(event string, r *models.Recipient, data mailer.TemplateData) *models.EmailDraft {
+	subject, text, html, err := mailer.Render(event, r.Language, data)
+	if err != nil {
+		log.Println("notify email:", err)
+		return nil
+	}
+	return &models.EmailDraft{
+		ToAddress: r.Email,
+		ToName:    r.FullName,
+		Subject:   subject,
+		TextBody:  text,
+		HTMLBody:  html,
+	}
+}
+
+// achievementURL is the link in emails: halaman prestasi di frontend
+// (FRONTEND_URL), atau API bila frontend tidak dikonfigurasi.
+func achievementURL(id string) string {
+	if base := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/"); base != "" {
+		return base + "/achievements/" + id
+	}
+	return utils.PublicBaseURL() + "/api/v1/achievements/" + id
+}
+
+// ListComments -> GET /api/v1/achievements/:id/comments
+func (s *AchievementService) ListComments(c *fiber.Ctx) error {
+	ar, _, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	page, err := s.Comments.FindPage(ar.ID, pageRequest(c))
+	if err != nil {
+		return respondPageError(c, err)
+	}
+	return c.JSON(page)
+}
+
+// AddComment -> POST /api/v1/achievements/:id/comments {"body": "..."}
+// Butuh permission achievement:comment dan akses lihat ke prestasinya.
+func (s *AchievementService) AddComment(c *fiber.Ctx) error {
+	var req models.CreateCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		return respondValidationError(c, models.ValidationErrors{"body": "required"})
+	}
+	if len([]rune(req.Body)) > 2000 {
+		return respondValidationError(c, models.ValidationErrors{"body": "must be at most 2000 characters"})
+	}
+	ar, actor, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if ar.Status == models.StatusDeleted {
+		return c.Status(409).JSON(fiber.Map{"error": "achievement is deleted"})
+	}
+
+	cm := &models.AchievementComment{
+		AchievementID: ar.ID,
+		UserID:        actor.UserID,
+		AuthorName:    actor.FullName,
+		AuthorRole:    actor.RoleName,
+		Body:          req.Body,
+	}
+	if err := s.Comments.Create(cm, s.notify(ar, models.EventAchievementCommented, actor, req.Body)); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(cm)
+}
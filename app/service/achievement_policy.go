@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// PermissionManageAchievements memberi akses global ke semua prestasi
+// (admin). Diputuskan dari permission role, bukan nama role, supaya role
+// admin tetap berlaku setelah diganti nama lewat /api/v1/roles.
+const PermissionManageAchievements = "achievement:manage"
+
+// ActorKind menentukan aturan akses yang berlaku untuk pemanggil.
+type ActorKind string
+
+const (
+	ActorAdmin    ActorKind = "admin"
+	ActorStudent  ActorKind = "student"
+	ActorLecturer ActorKind = "lecturer"
+	ActorNone     ActorKind = "none"
+)
+
+// AchievementAction is what the caller wants to do with an achievement.
+type AchievementAction string
+
+const (
+	ActionView   AchievementAction = "view"
+	ActionModify AchievementAction = "modify" // update, delete, submit, upload
+	ActionVerify AchievementAction = "verify" // verify, reject
+)
+
+// Actor is the authenticated caller resolved to its student/lecturer profile.
+type Actor struct {
+	UserID       string
+	RoleID       string
+	TokenVersion int // klaim tv token pemanggil, ikut ditandatangani di URL download
+	RoleName     string
+	FullName     string
+	Kind         ActorKind
+	StudentID    string // students.id, only for ActorStudent
+	LecturerID   string // lecturers.id, only for ActorLecturer
+}
+
+// PolicyError carries the HTTP status a denied/failed policy check maps to.
+type PolicyError struct {
+	Status  int
+	Message string
+}
+
+func (e *PolicyError) Error() string { return e.Message }
+
+func forbidden(msg string) error { return &PolicyError{Status: 403, Message: msg} }
+
+// respondPolicyError writes err as JSON using the PolicyError status (500 otherwise).
+func respondPolicyError(c *fiber.Ctx, err error) error {
+	var pe *PolicyError
+	if errors.As(err, &pe) {
+		return c.Status(pe.Status).JSON(fiber.Map{"error": pe.Message})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
+
+// AchievementPolicy adalah satu-satunya tempat aturan akses prestasi:
+//   - admin (permission achievement:manage): akses global
+//   - mahasiswa: hanya prestasi miliknya (students.user_id)
+//   - dosen wali: lihat & verifikasi prestasi mahasiswa bimbingannya (students.advisor_id)
+type AchievementPolicy struct {
+	UserRepo    *repository.UserRepository
+	StudentRepo *repository.StudentRepository
+	PGRepo      *repository.AchievementRepository
+	Revocations *repository.TokenRevocationRepository
+}
+
+func NewAchievementPolicy(userRepo *repository.UserRepository, studentRepo *repository.StudentRepository, pg *repository.AchievementRepository, revocations *repository.TokenRevocationRepository) *AchievementPolicy {
+	return &AchievementPolicy{
+		UserRepo:    userRepo,
+		StudentRepo: studentRepo,
+		PGRepo:      pg,
+		Revocations: revocations,
+	}
+}
+
+// Actor resolves the caller from the JWT locals.
+func (p *AchievementPolicy) Actor(c *fiber.Ctx) (*Actor, error) {
+	userID, _ := c.Locals("user_id").(string)
+	roleID, _ := c.Locals("role_id").(string)
+	if userID == "" {
+		return nil, &PolicyError{Status: 401, Message: "unauthorized"}
+	}
+	a, err := p.actorFor(userID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	a.TokenVersion, _ = c.Locals("token_version").(int)
+	return a, nil
+}
+
+// ActorByUserID resolves an actor without a JWT (e.g. from a signed URL
+// carrying the token version it was issued under), using the user's current
+// role. Inactive users get a 403; links issued before a logout-all/role
+// change are rejected like tokens.
+func (p *AchievementPolicy) ActorByUserID(userID string, tokenVersion int) (*Actor, error) {
+	revoked, err := p.Revocations.IsRevoked("", userID, tokenVersion)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, &PolicyError{Status: 401, Message: "link revoked"}
+	}
+	prof, err := p.UserRepo.FindActorProfile(userID, "", PermissionManageAchievements)
+	if err != nil {
+		return nil, err
+	}
+	if !prof.UserFound {
+		return nil, &PolicyError{Status: 401, Message: "unauthorized"}
+	}
+	if !prof.IsActive {
+		return nil, forbidden("user is inactive")
+	}
+	return actorFromProfile(userID, prof), nil
+}
+
+// actorFor resolves the actor's kind with one query. Profil yang tidak ada
+// berarti ActorNone; error database dikembalikan (500).
+func (p *AchievementPolicy) actorFor(userID, roleID string) (*Actor, error) {
+	prof, err := p.UserRepo.FindActorProfile(userID, roleID, PermissionManageAchievements)
+	if err != nil {
+		return nil, err
+	}
+	return actorFromProfile(userID, prof), nil
+}
+
+// actorFromProfile: admin mengalahkan profil mahasiswa, mahasiswa mengalahkan dosen.
+func actorFromProfile(userID string, prof *models.ActorProfile) *Actor {
+	a := &Actor{UserID: userID, RoleID: prof.RoleID, RoleName: prof.RoleName, FullName: prof.FullName, Kind: ActorNone}
+	switch {
+	case prof.HasPermission:
+		a.Kind = ActorAdmin
+	case prof.StudentID != "":
+		a.Kind = ActorStudent
+		a.StudentID = prof.StudentID
+	case prof.LecturerID != "":
+		a.Kind = ActorLecturer
+		a.LecturerID = prof.LecturerID
+	}
+	return a
+}
+
+// Check returns nil if actor may perform action on achievements of studentID.
+func (p *AchievementPolicy) Check(a *Actor, studentID string, action AchievementAction) error {
+	switch a.Kind {
+	case ActorAdmin:
+		return nil
+	case ActorStudent:
+		if action == ActionVerify {
+			return forbidden("students cannot verify achievements")
+		}
+		if studentID != a.StudentID {
+			return forbidden("achievement belongs to another student")
+		}
+		return nil
+	case ActorLecturer:
+		if action == ActionModify {
+			return forbidden("lecturers cannot modify achievements")
+		}
+		if _, err := uuid.Parse(studentID); err != nil {
+			return forbidden("student not found")
+		}
+		st, err := p.StudentRepo.FindById(studentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return forbidden("student not found")
+		}
+		if err != nil {
+			return err
+		}
+		if st.AdvisorID == nil || *st.AdvisorID != a.LecturerID {
+			return forbidden("student is not your advisee")
+		}
+		return nil
+	}
+	return forbidden("no student or lecturer profile for this user")
+}
+
+// Authorize loads the achievement reference and checks action against it.
+func (p *AchievementPolicy) Authorize(c *fiber.Ctx, achievementID string, action AchievementAction) (*models.AchievementReference, *Actor, error) {
+	a, err := p.Actor(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	ar, err := p.PGRepo.FindByID(achievementID)
+	if err != nil {
+		return nil, nil, &PolicyError{Status: 404, Message: "not found"}
+	}
+	if err := p.Check(a, ar.StudentID, action); err != nil {
+		return nil, nil, err
+	}
+	return ar, a, nil
+}
+
+// ScopeFilter narrows a requested list filter to what the actor may see. A
+// requested student_id outside the actor's scope is rejected.
+func (p *AchievementPolicy) ScopeFilter(a *Actor, f models.AchievementFilter) (models.AchievementFilter, error) {
+	switch a.Kind {
+	case ActorAdmin:
+		return f, nil
+	case ActorStudent:
+		// student_id dari query tidak dipercaya: mahasiswa selalu melihat miliknya sendiri
+		if f.StudentID != "" && f.StudentID != a.StudentID {
+			return f, forbidden("achievement belongs to another student")
+		}
+		f.StudentID = a.StudentID
+		f.AdvisorID = ""
+		return f, nil
+	case ActorLecturer:
+		if f.StudentID != "" {
+			if err := p.Check(a, f.StudentID, ActionView); err != nil {
+				return f, err
+			}
+		}
+		f.AdvisorID = a.LecturerID
+		return f, nil
+	}
+	return f, forbidden("no student or lecturer profile for this user")
+}
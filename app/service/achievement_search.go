@@ -0,0 +1,155 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// Kandidat text search Mongo dibaca per searchChunk lalu langsung disaring
+	// status/akses di Postgres, sampai habis atau searchScanLimit kandidat
+	// (hasil lalu ditandai truncated).
+	searchChunk        = 1000
+	searchScanLimit    = 20000
+	searchSnippetWidth = 160
+)
+
+// Search -> GET /api/v1/achievements/search?q=gemastik 2025&status=&student_id=&type=&page=&limit=
+// Relevansi dari text index Mongo; status dan hak akses disaring di Postgres.
+func (s *AchievementService) Search(c *fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "q required"})
+	}
+	if len(q) > 200 {
+		return c.Status(400).JSON(fiber.Map{"error": "q too long"})
+	}
+	actor, err := s.Policy.Actor(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	filter, err := s.Policy.ScopeFilter(actor, models.AchievementFilter{
+		StudentID: c.Query("student_id"),
+		Type:      c.Query("type"),
+		Statuses:  splitList(c.Query("status")),
+	})
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := validateStatusFilter(filter.Statuses); err != nil {
+		return respondPageError(c, err)
+	}
+
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	// persempit pencarian Mongo ke pemilik yang boleh dilihat (nil = semua)
+	var studentIDs []string
+	if filter.StudentID != "" {
+		studentIDs = []string{filter.StudentID}
+	} else if filter.AdvisorID != "" {
+		if studentIDs, err = s.Policy.StudentRepo.IDsByAdvisor(filter.AdvisorID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	// urutan relevansi dari Mongo, hanya yang lolos filter Postgres
+	var ranked []models.AchievementReference
+	var scores []float64
+	truncated := false
+	for scanned := 0; ; {
+		if scanned >= searchScanLimit {
+			truncated = true
+			break
+		}
+		matches, err := s.MongoRepo.TextSearch(q, studentIDs, int64(scanned), searchChunk)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		scanned += len(matches)
+		ids := make([]string, len(matches))
+		for i, m := range matches {
+			ids[i] = m.MongoID
+		}
+		refs, err := s.PGRepo.FindByMongoIDs(ids, filter)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		byMongo := make(map[string]models.AchievementReference, len(refs))
+		for _, ar := range refs {
+			byMongo[ar.MongoAchievementID] = ar
+		}
+		for _, m := range matches {
+			if ar, ok := byMongo[m.MongoID]; ok {
+				ranked = append(ranked, ar)
+				scores = append(scores, m.Score)
+			}
+		}
+		if len(matches) < searchChunk {
+			break
+		}
+	}
+	total := len(ranked)
+	from := (page - 1) * limit
+	if from > total {
+		from = total
+	}
+	to := from + limit
+	if to > total {
+		to = total
+	}
+
+	responses, err := s.buildAchievementResponses(ranked[from:to], nil, actor)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	terms := utils.SearchTerms(q)
+	hits := make([]models.SearchHit, len(responses))
+	for i, resp := range responses {
+		hits[i] = models.SearchHit{Achievement: resp, Score: scores[from+i], Highlights: highlights(resp.Doc, terms)}
+	}
+	return c.JSON(fiber.Map{
+		"data":  hits,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		// true: terlalu banyak kandidat, total dan halaman akhir tidak lengkap
+		"truncated": truncated,
+	})
+}
+
+// highlights returns the marked snippets of the searchable fields of doc.
+func highlights(doc map[string]interface{}, terms []string) map[string]string {
+	out := map[string]string{}
+	if doc == nil {
+		return out
+	}
+	if title, _ := doc["title"].(string); title != "" {
+		if h := utils.Highlight(title, terms, 0); h != "" {
+			out["title"] = h
+		}
+	}
+	if desc, _ := doc["description"].(string); desc != "" {
+		if h := utils.Highlight(desc, terms, searchSnippetWidth); h != "" {
+			out["description"] = h
+		}
+	}
+	extra, _ := doc["extra"].(map[string]interface{})
+	for _, key := range models.SearchableExtraFields {
+		if v, _ := extra[key].(string); v != "" {
+			if h := utils.Highlight(v, terms, searchSnippetWidth); h != "" {
+				out["extra."+key] = h
+			}
+		}
+	}
+	return out
+}
@@ -1,136 +1,185 @@
 package service
 
 import (
-	"context"
-	"io"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Lutfania/ekrp/app/models"
 	"github.com/Lutfania/ekrp/app/repository"
-	"github.com/Lutfania/ekrp/config"
+	"github.com/Lutfania/ekrp/mailer"
+	"github.com/Lutfania/ekrp/storage"
+	"github.com/Lutfania/ekrp/utils"
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/jackc/pgx/v5"
 )
 
 // AchievementService menangani logic yg gabungkan Postgres (reference) dan Mongo (dokumen prestasi)
 type AchievementService struct {
-	PGRepo    *repository.AchievementRepository
-	MongoRepo *repository.MongoAchievementRepository
+	PGRepo      *repository.AchievementRepository
+	MongoRepo   *repository.MongoAchievementRepository
+	HistoryRepo *repository.AchievementHistoryRepository
+	Policy      *AchievementPolicy
+	Points      *PointsService
+	Files       storage.Storage
+	Audit       *repository.AuditRepository
+	Uploads     *UploadPolicyService
+	Previews    *PreviewWorker
+	Comments    *repository.AchievementCommentRepository
+	Mailer      mailer.Sender // nil = email nonaktif; dikirim oleh EmailWorker
 }
 
-func NewAchievementService(pg *repository.AchievementRepository, mongo *repository.MongoAchievementRepository) *AchievementService {
-	return &AchievementService{PGRepo: pg, MongoRepo: mongo}
+func NewAchievementService(pg *repository.AchievementRepository, mongo *repository.MongoAchievementRepository, history *repository.AchievementHistoryRepository, policy *AchievementPolicy, points *PointsService, files storage.Storage, audit *repository.AuditRepository, uploads *UploadPolicyService, previews *PreviewWorker, comments *repository.AchievementCommentRepository, mail mailer.Sender) *AchievementService {
+	return &AchievementService{PGRepo: pg, MongoRepo: mongo, HistoryRepo: history, Policy: policy, Points: points, Files: files, Audit: audit, Uploads: uploads, Previews: previews, Comments: comments, Mailer: mail}
 }
 
-// List -> GET /api/v1/achievements?student_id=...
+// List -> GET /api/v1/achievements?student_id=&type=&status=draft,submitted&from=&to=&limit=&cursor=&sort=&total=
+// ?fields=title,extra.level memilih field dokumen Mongo (fields=none: tanpa dokumen).
 func (s *AchievementService) List(c *fiber.Ctx) error {
-	roleRaw := c.Locals("role_id")
-	studentIDQuery := c.Query("student_id")
-
-	// simplify: if admin (role name/id "Admin") => list all or filter by student
-	if roleStr, ok := roleRaw.(string); ok && roleStr == "Admin" {
-		if studentIDQuery != "" {
-			list, err := s.PGRepo.ListByStudent(studentIDQuery)
-			if err != nil {
-				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-			}
-			return s.buildAchievementResponses(list, true)
-		}
-		list, err := s.PGRepo.ListAll()
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-		}
-		return s.buildAchievementResponses(list, true)
+	actor, err := s.Policy.Actor(c)
+	if err != nil {
+		return respondPolicyError(c, err)
 	}
 
-	// non-admin (mahasiswa) — require student_id param (or adapt mapping user->student)
-	if studentIDQuery == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "student_id required"})
+	// scope ditentukan policy: admin semua, mahasiswa miliknya, dosen wali bimbingannya
+	filter, err := s.Policy.ScopeFilter(actor, models.AchievementFilter{
+		StudentID: c.Query("student_id"),
+		Type:      c.Query("type"),
+		Statuses:  splitList(c.Query("status")),
+	})
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := validateStatusFilter(filter.Statuses); err != nil {
+		return respondPageError(c, err)
 	}
-	list, err := s.PGRepo.ListByStudent(studentIDQuery)
+	if filter.From, filter.To, err = dateRange(c, "from", "to"); err != nil {
+		return respondPageError(c, err)
+	}
+	fields, err := models.ParseDocFields(c.Query("fields"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	page, err := s.PGRepo.FindPage(filter, pageRequest(c))
+	if err != nil {
+		return respondPageError(c, err)
+	}
+	data, err := s.buildAchievementResponses(page.Data, fields, actor)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
-	return s.buildAchievementResponses(list, false)
+	return c.JSON(models.PageResponse[models.AchievementResponse]{
+		Data:       data,
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	})
 }
 
-// helper: build responses merging mongo doc
-func (s *AchievementService) buildAchievementResponses(list []models.AchievementReference, includeAll bool) error {
-	// not used; placeholder to satisfy signature if used elsewhere
-	_ = includeAll
+// validateStatusFilter rejects unknown statuses in ?status= (deleted is never listed).
+func validateStatusFilter(statuses []string) error {
+	for _, st := range statuses {
+		if _, ok := models.AchievementTransitions[st]; !ok || st == models.StatusDeleted {
+			return &models.PageError{Message: "unknown status " + st}
+		}
+	}
 	return nil
 }
 
-// buildAchievementResponses returns JSON result — implementation returns in caller instead of here
-func (s *AchievementService) buildAchievementResponsesWithData(list []models.AchievementReference) ([]models.AchievementResponse, error) {
-	var out []models.AchievementResponse
+// buildAchievementResponses merges the Mongo documents into list with one
+// $in query. fields limits the document fields (nil = all, empty = no doc);
+// preview URL file ditandatangani untuk viewer.
+func (s *AchievementService) buildAchievementResponses(list []models.AchievementReference, fields []string, viewer *Actor) ([]models.AchievementResponse, error) {
+	docs := map[string]*models.MongoAchievement{}
+	if fields == nil || len(fields) > 0 {
+		ids := make([]string, 0, len(list))
+		for _, ar := range list {
+			if ar.MongoAchievementID != "" {
+				ids = append(ids, ar.MongoAchievementID)
+			}
+		}
+		var err error
+		if docs, err = s.MongoRepo.FindByIDHexes(ids, fields...); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]models.AchievementResponse, 0, len(list))
 	for _, ar := range list {
 		resp := models.AchievementResponse{
 			ID:                 ar.ID,
 			StudentID:          ar.StudentID,
 			MongoAchievementID: ar.MongoAchievementID,
 			Status:             ar.Status,
+			AchievementType:    ar.AchievementType,
 			SubmittedAt:        ar.SubmittedAt,
 			VerifiedAt:         ar.VerifiedAt,
 			VerifiedBy:         ar.VerifiedBy,
 			RejectionNote:      ar.RejectionNote,
 			CreatedAt:          ar.CreatedAt,
 			UpdatedAt:          ar.UpdatedAt,
+			Points:             ar.Points,
 		}
-
-		// try fetch mongo doc if exists
-		if ar.MongoAchievementID != "" {
-			doc, err := s.MongoRepo.FindByIDHex(ar.MongoAchievementID)
-			if err == nil && doc != nil {
-				// adapt doc into map[string]interface{} for response
-				m := map[string]interface{}{
-					"id":          doc.ID,
-					"title":       doc.Title,
-					"description": doc.Description,
-					"files":       doc.Files,
-					"extra":       doc.Extra,
-					"created_at":  doc.CreatedAt,
-					"updated_at":  doc.UpdatedAt,
-				}
-				resp.Doc = m
-			}
+		if doc, ok := docs[ar.MongoAchievementID]; ok {
+			withPreviewURLs(ar.ID, doc.Files, viewer)
+			resp.Doc = docMap(doc, fields)
 		}
 		out = append(out, resp)
 	}
 	return out, nil
 }
 
+// docMap adapts doc into the response map, keeping only the selected fields.
+func docMap(doc *models.MongoAchievement, fields []string) map[string]interface{} {
+	m := map[string]interface{}{
+		"id":          doc.ID,
+		"title":       doc.Title,
+		"description": doc.Description,
+		"files":       doc.Files,
+		"extra":       doc.Extra,
+		"version":     doc.Version,
+		"created_at":  doc.CreatedAt,
+		"updated_at":  doc.UpdatedAt,
+	}
+	if len(fields) == 0 {
+		return m
+	}
+	selected := map[string]interface{}{"id": doc.ID}
+	for _, f := range fields {
+		// extra.level -> extra (projection Mongo sudah memangkas isi extra)
+		top := strings.SplitN(f, ".", 2)[0]
+		selected[top] = m[top]
+	}
+	return selected
+}
+
 // GetByID -> GET /api/v1/achievements/:id
 func (s *AchievementService) GetByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	ar, err := s.PGRepo.FindByID(id)
+	ar, actor, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "not found"})
+		return respondPolicyError(c, err)
 	}
 	resp := models.AchievementResponse{
 		ID:                 ar.ID,
 		StudentID:          ar.StudentID,
 		MongoAchievementID: ar.MongoAchievementID,
 		Status:             ar.Status,
+		AchievementType:    ar.AchievementType,
 		SubmittedAt:        ar.SubmittedAt,
 		VerifiedAt:         ar.VerifiedAt,
 		VerifiedBy:         ar.VerifiedBy,
 		RejectionNote:      ar.RejectionNote,
 		CreatedAt:          ar.CreatedAt,
 		UpdatedAt:          ar.UpdatedAt,
+		Points:             ar.Points,
 	}
 	if ar.MongoAchievementID != "" {
 		doc, err := s.MongoRepo.FindByIDHex(ar.MongoAchievementID)
 		if err == nil && doc != nil {
-			resp.Doc = map[string]interface{}{
-				"id":          doc.ID,
-				"title":       doc.Title,
-				"description": doc.Description,
-				"files":       doc.Files,
-				"extra":       doc.Extra,
-				"created_at":  doc.CreatedAt,
-				"updated_at":  doc.UpdatedAt,
-			}
+			withPreviewURLs(ar.ID, doc.Files, actor)
+			resp.Doc = docMap(doc, nil)
 		}
 	}
 	return c.JSON(resp)
@@ -143,15 +192,34 @@ func (s *AchievementService) Create(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
 	}
+
+	actor, err := s.Policy.Actor(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	// mahasiswa boleh tidak mengisi student_id: otomatis miliknya sendiri
+	if req.StudentID == "" && actor.Kind == ActorStudent {
+		req.StudentID = actor.StudentID
+	}
 	if req.StudentID == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "student_id required"})
 	}
+	if err := s.Policy.Check(actor, req.StudentID, ActionModify); err != nil {
+		return respondPolicyError(c, err)
+	}
+
+	// field sesuai tipe (level, rank, organiser, ...) disimpan di Extra
+	if err := models.ValidateAchievement(req.AchievementType, req.Title, req.Doc); err != nil {
+		return respondValidationError(c, err)
+	}
 
-	// build mongo document from req.Doc; we'll store under Extra field
 	mongoDoc := &models.MongoAchievement{
-		StudentID:   req.StudentID,
-		Extra:       req.Doc,
-		CreatedAt:   time.Now(),
+		StudentID:       req.StudentID,
+		AchievementType: req.AchievementType,
+		Title:           req.Title,
+		Description:     req.Description,
+		Extra:           req.Doc,
+		CreatedAt:       time.Now(),
 	}
 	hexID, err := s.MongoRepo.Insert(mongoDoc)
 	if err != nil {
@@ -162,7 +230,8 @@ func (s *AchievementService) Create(c *fiber.Ctx) error {
 	ar := &models.AchievementReference{
 		StudentID:          req.StudentID,
 		MongoAchievementID: hexID,
-		Status:             "draft",
+		Status:             models.StatusDraft,
+		AchievementType:    req.AchievementType,
 		CreatedAt:          now,
 	}
 	if err := s.PGRepo.Create(ar); err != nil {
@@ -171,48 +240,185 @@ func (s *AchievementService) Create(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(201).JSON(fiber.Map{"message": "created", "mongo_id": hexID})
+	return c.Status(201).JSON(fiber.Map{"message": "created", "id": ar.ID, "mongo_id": hexID})
 }
 
 // Update -> PUT /api/v1/achievements/:id
+// body: {"title", "description", "extra"} — mengganti seluruh konten dokumen
 func (s *AchievementService) Update(c *fiber.Ctx) error {
-	id := c.Params("id")
 	var req models.UpdateAchievementRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
 	}
 
-	ar, err := s.PGRepo.FindByID(id)
+	return s.editContent(c, func(doc *models.MongoAchievement) (*models.MongoAchievement, error) {
+		return &models.MongoAchievement{Title: req.Title, Description: req.Description, Extra: req.Extra}, nil
+	})
+}
+
+// Patch -> PATCH /api/v1/achievements/:id
+// body: JSON Merge Patch (RFC 7386) atas {"title", "description", "extra"}
+func (s *AchievementService) Patch(c *fiber.Ctx) error {
+	var patch map[string]interface{}
+	if err := json.Unmarshal(c.Body(), &patch); err != nil || patch == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid merge patch"})
+	}
+	for k := range patch {
+		if k != "title" && k != "description" && k != "extra" {
+			return c.Status(400).JSON(fiber.Map{"error": "unknown field: " + k})
+		}
+	}
+
+	return s.editContent(c, func(doc *models.MongoAchievement) (*models.MongoAchievement, error) {
+		out := &models.MongoAchievement{Title: doc.Title, Description: doc.Description, Extra: doc.Extra}
+		if v, ok := patch["title"]; ok {
+			str, isStr := v.(string)
+			if v != nil && !isStr {
+				return nil, errors.New("title must be a string")
+			}
+			out.Title = str
+		}
+		if v, ok := patch["description"]; ok {
+			str, isStr := v.(string)
+			if v != nil && !isStr {
+				return nil, errors.New("description must be a string")
+			}
+			out.Description = str
+		}
+		if v, ok := patch["extra"]; ok {
+			switch p := v.(type) {
+			case nil:
+				out.Extra = nil
+			case map[string]interface{}:
+				current, _ := utils.NormalizeBSON(doc.Extra).(map[string]interface{})
+				out.Extra = utils.MergePatch(current, p)
+			default:
+				return nil, errors.New("extra must be an object")
+			}
+		}
+		return out, nil
+	})
+}
+
+// editContent runs the shared PUT/PATCH flow: access + status check, optional
+// If-Match version check, then a versioned update of the Mongo document.
+func (s *AchievementService) editContent(c *fiber.Ctx, apply func(doc *models.MongoAchievement) (*models.MongoAchievement, error)) error {
+	ar, actor, err := s.Policy.Authorize(c, c.Params("id"), ActionModify)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "not found"})
+		return respondPolicyError(c, err)
+	}
+	if !models.IsEditableStatus(ar.Status) {
+		return c.Status(409).JSON(fiber.Map{"error": "achievement can only be edited in draft or rejected status", "current_status": ar.Status})
 	}
 
-	// update mongo doc if provided
-	if req.MongoAchievementID != nil && *req.MongoAchievementID != "" {
-		// update PG record's mongo id
-		if err := s.PGRepo.UpdateMongoID(id, *req.MongoAchievementID); err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	doc, err := s.MongoRepo.FindByIDHex(ar.MongoAchievementID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "achievement document not found"})
+	}
+	if ifMatch := c.Get(fiber.HeaderIfMatch); ifMatch != "" && ifMatch != strconv.Itoa(doc.Version) {
+		return c.Status(412).JSON(fiber.Map{"error": "version mismatch", "current_version": doc.Version})
+	}
+
+	next, err := apply(doc)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	// dokumen lama tanpa tipe tidak punya skema untuk divalidasi
+	if doc.AchievementType != "" {
+		if err := models.ValidateAchievement(doc.AchievementType, next.Title, utils.NormalizeBSON(next.Extra).(map[string]interface{})); err != nil {
+			return respondValidationError(c, err)
 		}
-		ar.MongoAchievementID = *req.MongoAchievementID
 	}
 
-	return c.JSON(fiber.Map{"message": "updated"})
+	version, err := s.MongoRepo.UpdateContent(doc, next.Title, next.Description, next.Extra, actor.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "updated", "version": version})
+}
+
+// Versions -> GET /api/v1/achievements/:id/versions
+func (s *AchievementService) Versions(c *fiber.Ctx) error {
+	ar, _, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	versions, err := s.MongoRepo.ListVersions(ar.MongoAchievementID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(versions)
+}
+
+// Version -> GET /api/v1/achievements/:id/versions/:version
+func (s *AchievementService) Version(c *fiber.Ctx) error {
+	ar, _, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	n, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid version"})
+	}
+	v, err := s.MongoRepo.FindVersion(ar.MongoAchievementID, n)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "version not found"})
+	}
+	return c.JSON(v)
+}
+
+// DiffVersions -> GET /api/v1/achievements/:id/versions/diff?from=1&to=2
+func (s *AchievementService) DiffVersions(c *fiber.Ctx) error {
+	ar, _, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "from and to versions required"})
+	}
+
+	a, err := s.MongoRepo.FindVersion(ar.MongoAchievementID, from)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "version " + strconv.Itoa(from) + " not found"})
+	}
+	b, err := s.MongoRepo.FindVersion(ar.MongoAchievementID, to)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "version " + strconv.Itoa(to) + " not found"})
+	}
+
+	return c.JSON(fiber.Map{
+		"from":    from,
+		"to":      to,
+		"changes": utils.DiffMaps(versionContent(a), versionContent(b)),
+	})
+}
+
+func versionContent(v *models.AchievementVersion) map[string]interface{} {
+	m := map[string]interface{}{
+		"title":       v.Title,
+		"description": v.Description,
+	}
+	if v.Extra != nil {
+		m["extra"] = utils.NormalizeBSON(v.Extra)
+	}
+	return m
 }
 
 // Delete -> DELETE /api/v1/achievements/:id
 func (s *AchievementService) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
-	ar, err := s.PGRepo.FindByID(id)
+	_, actor, err := s.Policy.Authorize(c, id, ActionModify)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "not found"})
+		return respondPolicyError(c, err)
 	}
-	// delete mongo doc if exist
-	if ar.MongoAchievementID != "" {
-		_ = s.MongoRepo.DeleteByHex(ar.MongoAchievementID)
-	}
-	// delete pg reference
-	if err := s.PGRepo.Delete(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	// soft delete: hanya draft/rejected, dokumen Mongo tetap disimpan untuk riwayat
+	if err := s.transition(c, actor, id, models.StatusChange{To: models.StatusDeleted}, ""); err != nil {
+		return respondTransitionError(c, err)
 	}
 	return c.JSON(fiber.Map{"message": "deleted"})
 }
@@ -220,28 +426,63 @@ func (s *AchievementService) Delete(c *fiber.Ctx) error {
 // Submit -> POST /api/v1/achievements/:id/submit
 func (s *AchievementService) Submit(c *fiber.Ctx) error {
 	id := c.Params("id")
+	ar, actor, err := s.Policy.Authorize(c, id, ActionModify)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
 	now := time.Now()
-	if err := s.PGRepo.UpdateStatus(id, "submitted", &now, nil, nil, nil); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	change := models.StatusChange{
+		To:          models.StatusSubmitted,
+		SubmittedAt: &now,
+		ResetReview: true,
+		Notify:      s.notifier(ar, models.EventAchievementSubmitted, actor, ""),
+	}
+	if err := s.transition(c, actor, id, change, ""); err != nil {
+		return respondTransitionError(c, err)
 	}
-	// optional history: insert to history table if exists (best effort)
-	_ = insertHistoryIfTableExists(id, "draft", "submitted", c.Locals("user_id"))
 	return c.JSON(fiber.Map{"message": "submitted"})
 }
 
 // Verify -> POST /api/v1/achievements/:id/verify
 func (s *AchievementService) Verify(c *fiber.Ctx) error {
 	id := c.Params("id")
+	ar, actor, err := s.Policy.Authorize(c, id, ActionVerify)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
 	now := time.Now()
 	verifier, _ := c.Locals("user_id").(string)
 	if verifier == "" {
 		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
 	}
-	if err := s.PGRepo.UpdateStatus(id, "verified", nil, &now, &verifier, nil); err != nil {
+	points, ruleSetID, err := s.Points.Compute(ar, now)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "cannot compute points: " + err.Error()})
+	}
+	code, err := utils.GenerateVerificationCode()
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
-	_ = insertHistoryIfTableExists(id, "submitted", "verified", verifier)
-	return c.JSON(fiber.Map{"message": "verified"})
+	change := models.StatusChange{
+		To:               models.StatusVerified,
+		VerifiedAt:       &now,
+		VerifiedBy:       &verifier,
+		Points:           &points,
+		PointRuleSetID:   ruleSetID,
+		VerificationCode: code,
+		Notify:           s.notifier(ar, models.EventAchievementVerified, actor, ""),
+	}
+	if err := s.transition(c, actor, id, change, ""); err != nil {
+		return respondTransitionError(c, err)
+	}
+	invalidateLeaderboards()
+	return c.JSON(fiber.Map{
+		"message":           "verified",
+		"points":            points,
+		"verification_code": code,
+		"verification_url":  utils.VerificationURL(code),
+		"qr_code_url":       "/api/v1/achievements/" + id + "/verification/qr",
+	})
 }
 
 // Reject -> POST /api/v1/achievements/:id/reject
@@ -251,100 +492,121 @@ func (s *AchievementService) Reject(c *fiber.Ctx) error {
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
 	}
+	if strings.TrimSpace(body.Note) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "note required"})
+	}
+	ar, actor, err := s.Policy.Authorize(c, id, ActionVerify)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
 	verifier, _ := c.Locals("user_id").(string)
 	if verifier == "" {
 		return c.Status(403).JSON(fiber.Map{"error": "forbidden"})
 	}
-	if err := s.PGRepo.UpdateStatus(id, "rejected", nil, nil, &verifier, &body.Note); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	change := models.StatusChange{
+		To:            models.StatusRejected,
+		VerifiedBy:    &verifier,
+		RejectionNote: &body.Note,
+		Notify:        s.notifier(ar, models.EventAchievementRejected, actor, body.Note),
+	}
+	if err := s.transition(c, actor, id, change, body.Note); err != nil {
+		return respondTransitionError(c, err)
 	}
-	_ = insertHistoryIfTableExists(id, "submitted", "rejected", verifier)
 	return c.JSON(fiber.Map{"message": "rejected"})
 }
 
-// History -> GET /api/v1/achievements/:id/history
-func (s *AchievementService) History(c *fiber.Ctx) error {
+// Revise -> POST /api/v1/achievements/:id/revise (rejected -> draft)
+func (s *AchievementService) Revise(c *fiber.Ctx) error {
 	id := c.Params("id")
-	// try reading dedicated history table; fallback to returning single reference
-	rows, err := config.DB.Query(context.Background(),
-		`SELECT id, old_status, new_status, changed_by, note, changed_at
-		 FROM achievement_reference_history WHERE achievement_ref_id=$1 ORDER BY changed_at DESC`, id)
+	_, actor, err := s.Policy.Authorize(c, id, ActionModify)
 	if err != nil {
-		// fallback: return current record
-		ar, err2 := s.PGRepo.FindByID(id)
-		if err2 != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err2.Error()})
-		}
-		return c.JSON([]models.AchievementReference{*ar})
-	}
-	defer rows.Close()
-
-	var history []map[string]interface{}
-	for rows.Next() {
-		var hid, oldS, newS string
-		var changedBy, note *string
-		var changedAt time.Time
-		rows.Scan(&hid, &oldS, &newS, &changedBy, &note, &changedAt)
-		history = append(history, map[string]interface{}{
-			"id":         hid, "old_status": oldS, "new_status": newS,
-			"changed_by": changedBy, "note": note, "changed_at": changedAt,
-		})
+		return respondPolicyError(c, err)
+	}
+	if err := s.transition(c, actor, id, models.StatusChange{To: models.StatusDraft, ResetReview: true}, ""); err != nil {
+		return respondTransitionError(c, err)
 	}
-	return c.JSON(history)
+	return c.JSON(fiber.Map{"message": "returned to draft"})
 }
 
-// UploadAttachment -> POST /api/v1/achievements/:id/attachments
-// multipart/form-data; field "file" (single)
-func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
+// Archive -> POST /api/v1/achievements/:id/archive (verified -> archived)
+func (s *AchievementService) Archive(c *fiber.Ctx) error {
 	id := c.Params("id")
-	ar, err := s.PGRepo.FindByID(id)
+	_, actor, err := s.Policy.Authorize(c, id, ActionModify)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "achievement not found"})
+		return respondPolicyError(c, err)
 	}
-	mongoHex := ar.MongoAchievementID
-	if mongoHex == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "no mongo document linked"})
+	if err := s.transition(c, actor, id, models.StatusChange{To: models.StatusArchived}, ""); err != nil {
+		return respondTransitionError(c, err)
 	}
+	return c.JSON(fiber.Map{"message": "archived"})
+}
 
-	fileHeader, err := c.FormFile("file")
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "file required"})
-	}
+// transition applies a guarded status change; the history row (actual previous
+// status, note, actor and request metadata) is written in the same transaction.
+func (s *AchievementService) transition(c *fiber.Ctx, actor *Actor, id string, change models.StatusChange, note string) error {
+	_, err := s.PGRepo.TransitionStatus(id, change, historyMeta(c, actor, note))
+	return err
+}
 
-	// read file content if needed (here we won't store to disk; just metadata). In production, upload to storage (S3) and save URL.
-	f, err := fileHeader.Open()
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "cannot open file"})
+func historyMeta(c *fiber.Ctx, actor *Actor, note string) models.HistoryMeta {
+	return models.HistoryMeta{
+		ActorID:   actor.UserID,
+		ActorName: actor.FullName,
+		ActorRole: actor.RoleName,
+		Note:      note,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		RequestID: c.Get(fiber.HeaderXRequestID),
 	}
-	defer f.Close()
-	// read small preview or skip reading large file; we'll discard content
-	_, _ = io.Copy(io.Discard, f)
+}
 
-	fileMeta := map[string]interface{}{
-		"file_name":   fileHeader.Filename,
-		"file_size":   fileHeader.Size,
-		"content_type": fileHeader.Header.Get("Content-Type"),
-		"uploaded_at": time.Now(),
-		// "file_url": "https://... if you upload to storage"
+// respondValidationError returns 422 with field-level messages.
+func respondValidationError(c *fiber.Ctx, err error) error {
+	var ve models.ValidationErrors
+	if errors.As(err, &ve) {
+		return c.Status(422).JSON(fiber.Map{"error": "validation failed", "fields": ve})
 	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
 
-	// push into mongo "files" array
-	if err := s.MongoRepo.UpdateByHex(mongoHex, bson.M{"$push": bson.M{"files": fileMeta}}); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-	}
+// AchievementTypes -> GET /api/v1/achievement-types
+func (s *AchievementService) AchievementTypes(c *fiber.Ctx) error {
+	return c.JSON(models.AchievementTypeList())
+}
 
-	return c.JSON(fiber.Map{"message": "attachment uploaded"})
+// respondTransitionError maps an illegal move to 409 with the allowed next states.
+func respondTransitionError(c *fiber.Ctx, err error) error {
+	var te *models.TransitionError
+	if errors.As(err, &te) {
+		return c.Status(409).JSON(fiber.Map{
+			"error":          te.Error(),
+			"current_status": te.From,
+			"allowed":        te.Allowed,
+		})
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(404).JSON(fiber.Map{"error": "not found"})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 }
 
-/*** small helper ***/
-func insertHistoryIfTableExists(achievementRefID, oldStatus, newStatus string, changedBy interface{}) error {
-	// best-effort insert; if table doesn't exist it will error and we ignore
-	_, err := config.DB.Exec(context.Background(),
-		`INSERT INTO achievement_reference_history (id, achievement_ref_id, old_status, new_status, changed_by, note, changed_at)
-		 VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, now())`, achievementRefID, oldStatus, newStatus, changedBy, nil)
+// History -> GET /api/v1/achievements/:id/history?limit=&cursor=&sort=-changed_at&total=
+// IP, user agent dan request ID pelaku hanya untuk admin.
+func (s *AchievementService) History(c *fiber.Ctx) error {
+	id := c.Params("id")
+	_, actor, err := s.Policy.Authorize(c, id, ActionView)
 	if err != nil {
-		// ignore error (table may not exist)
-		return err
+		return respondPolicyError(c, err)
 	}
-	return nil
+
+	page, err := s.HistoryRepo.FindPage(id, pageRequest(c))
+	if err != nil {
+		return respondPageError(c, err)
+	}
+	if actor.Kind != ActorAdmin {
+		for i, h := range page.Data {
+			page.Data[i] = h.WithoutRequestMeta()
+		}
+	}
+	return c.JSON(page)
 }
@@ -1,6 +1,10 @@
 package service
 
 import (
+	"errors"
+	"log"
+	"time"
+
 	"github.com/Lutfania/ekrp/app/models"
 	"github.com/Lutfania/ekrp/app/repository"
 	"github.com/Lutfania/ekrp/utils"
@@ -9,12 +13,18 @@ import (
 )
 
 type AuthService struct {
-	UserRepo *repository.UserRepository
+	UserRepo       *repository.UserRepository
+	PermissionRepo *repository.PermissionRepository
+	RefreshRepo    *repository.RefreshTokenRepository
+	RevocationRepo *repository.TokenRevocationRepository
 }
 
-func NewAuthService(repo *repository.UserRepository) *AuthService {
+func NewAuthService(repo *repository.UserRepository, permRepo *repository.PermissionRepository, refreshRepo *repository.RefreshTokenRepository, revocationRepo *repository.TokenRevocationRepository) *AuthService {
 	return &AuthService{
-		UserRepo: repo,
+		UserRepo:       repo,
+		PermissionRepo: permRepo,
+		RefreshRepo:    refreshRepo,
+		RevocationRepo: revocationRepo,
 	}
 }
 
@@ -36,27 +46,38 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
 		return c.Status(401).JSON(fiber.Map{"error": "invalid email or password"})
 	}
+	if !user.IsActive {
+		return c.Status(403).JSON(fiber.Map{"error": "user is deactivated"})
+	}
 
 	// Ambil permissions dari role
-	permissions, _ := s.UserRepo.GetRolePermissions(user.RoleID)
+	permissions, _ := s.PermissionRepo.GetPermissionsByRole(user.RoleID)
 
 	token, err := utils.GenerateTokenWithPermissions(
 		user.ID,
 		user.RoleID,
+		user.TokenVersion,
 		permissions,
 	)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to generate token"})
 	}
 
+	// login baru => family refresh token baru
+	refreshToken, err := s.issueRefreshToken(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate refresh token"})
+	}
+
 	return c.JSON(models.LoginResponse{
-		ID:          user.ID,
-		Username:    user.Username,
-		Email:       user.Email,
-		FullName:    user.FullName,
-		RoleID:      user.RoleID,
-		Token:       token,
-		Permissions: permissions,
+		ID:           user.ID,
+		Username:     user.Username,
+		Email:        user.Email,
+		FullName:     user.FullName,
+		RoleID:       user.RoleID,
+		Token:        token,
+		RefreshToken: refreshToken,
+		Permissions:  permissions,
 	})
 }
 
@@ -70,14 +91,138 @@ func (s *AuthService) Profile(c *fiber.Ctx) error {
 
 // ------------------------------------
 // POST /auth/logout
+// body (optional): {"refresh_token": "..."}
 // ------------------------------------
 func (s *AuthService) Logout(c *fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(*utils.Claims)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	if err := s.RevocationRepo.RevokeToken(claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// cabut juga sesi refresh token milik device ini kalau dikirim
+	var req models.RefreshRequest
+	_ = c.BodyParser(&req)
+	if req.RefreshToken != "" {
+		stored, err := s.RefreshRepo.FindByHash(utils.HashRefreshToken(req.RefreshToken))
+		if err == nil && stored.UserID == claims.UserID {
+			if err := s.RefreshRepo.RevokeFamily(stored.FamilyID); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+	}
+
 	return c.JSON(fiber.Map{"message": "logged out"})
 }
 
+// ------------------------------------
+// POST /auth/logout-all
+// ------------------------------------
+func (s *AuthService) LogoutAll(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if err := revokeUserSessions(s.RevocationRepo, s.RefreshRepo, userID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "logged out from all devices"})
+}
+
+// RevokeUserSessions invalidates every access and refresh token of a user.
+func revokeUserSessions(revocations *repository.TokenRevocationRepository, refreshTokens *repository.RefreshTokenRepository, userID string) error {
+	if err := revocations.RevokeUser(userID); err != nil {
+		return err
+	}
+	return refreshTokens.RevokeAllForUser(userID)
+}
+
 // ------------------------------------
 // POST /auth/refresh
 // ------------------------------------
 func (s *AuthService) RefreshToken(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{"message": "not implemented"})
+	var req models.RefreshRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "refresh_token required"})
+	}
+
+	stored, err := s.RefreshRepo.FindByHash(utils.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid refresh token"})
+	}
+
+	// token yang sudah dipakai/di-revoke muncul lagi => kemungkinan dicuri,
+	// cabut seluruh family supaya pemegang token manapun harus login ulang
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		_ = s.RefreshRepo.RevokeFamily(stored.FamilyID)
+		return c.Status(401).JSON(fiber.Map{"error": "refresh token reused"})
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return c.Status(401).JSON(fiber.Map{"error": "refresh token expired"})
+	}
+
+	user, err := s.UserRepo.FindById(stored.UserID)
+	if err != nil || !user.IsActive {
+		_ = s.RefreshRepo.RevokeFamily(stored.FamilyID)
+		return c.Status(401).JSON(fiber.Map{"error": "user not active"})
+	}
+
+	exp, err := utils.RefreshTokenExpiry()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "invalid REFRESH_EXPIRE_HOURS"})
+	}
+	raw, hash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate refresh token"})
+	}
+	if err := s.RefreshRepo.Rotate(stored, hash, time.Now().Add(exp)); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			_ = s.RefreshRepo.RevokeFamily(stored.FamilyID)
+			return c.Status(401).JSON(fiber.Map{"error": "refresh token reused"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	permissions, _ := s.PermissionRepo.GetPermissionsByRole(user.RoleID)
+	// permission dibaca setelah versi token (FindById): bila role berubah di
+	// antaranya, token ini sudah membawa versi lama dan ditolak
+	token, err := utils.GenerateTokenWithPermissions(user.ID, user.RoleID, user.TokenVersion, permissions)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to generate token"})
+	}
+
+	return c.JSON(models.RefreshResponse{
+		Token:        token,
+		RefreshToken: raw,
+	})
+}
+
+// StartRevocationJanitor deletes revoked jtis of expired tokens every interval.
+func (s *AuthService) StartRevocationJanitor(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := s.RevocationRepo.DeleteExpired(); err != nil {
+				log.Println("auth: cannot delete expired revocations:", err)
+			}
+		}
+	}()
+}
+
+// issueRefreshToken creates and stores a refresh token that starts a new family.
+func (s *AuthService) issueRefreshToken(userID string) (string, error) {
+	exp, err := utils.RefreshTokenExpiry()
+	if err != nil {
+		return "", err
+	}
+	raw, hash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.RefreshRepo.Create(userID, hash, time.Now().Add(exp)); err != nil {
+		return "", err
+	}
+	return raw, nil
 }
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/credential"
+	"github.com/Lutfania/ekrp/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Open Badges 3.0: prestasi terverifikasi sebagai AchievementCredential
+// (W3C Verifiable Credential 2.0) dengan DataIntegrityProof eddsa-jcs-2022,
+// ditandatangani kunci yang sama dengan kredensial JWS.
+
+var openBadgeContext = []string{
+	"https://www.w3.org/ns/credentials/v2",
+	"https://purl.imsglobal.org/spec/ob/v3p0/context-3.0.3.json",
+}
+
+// achievementType Open Badges untuk tiap tipe prestasi ("ext:" = ekstensi).
+var openBadgeAchievementTypes = map[string]string{
+	models.TypeCompetition:      "Award",
+	models.TypePublication:      "ext:Publication",
+	models.TypeCertification:    "Certification",
+	models.TypeOrganisation:     "Membership",
+	models.TypeCommunityService: "CommunityService",
+}
+
+// openBadgeIssuerID is the URL of the issuer profile; verificationMethod
+// kunci = issuer ID + "#" + kid.
+func openBadgeIssuerID() string {
+	return utils.PublicBaseURL() + "/api/v1/openbadges/issuer"
+}
+
+func docString(doc map[string]interface{}, key string) string {
+	v, _ := doc[key].(string)
+	return v
+}
+
+// openBadge maps one verified achievement to an unsigned OB 3.0 credential.
+func openBadge(ar models.AchievementResponse, subject *models.CredentialSubject) map[string]interface{} {
+	title := docString(ar.Doc, "title")
+	achievement := map[string]interface{}{
+		"id":          "urn:uuid:" + ar.ID,
+		"type":        []string{"Achievement"},
+		"name":        title,
+		"description": docString(ar.Doc, "description"),
+		"criteria": map[string]interface{}{
+			"narrative": "Prestasi diverifikasi oleh " + utils.InstitutionName() + " berdasarkan bukti yang dilampirkan mahasiswa.",
+		},
+	}
+	if t, ok := openBadgeAchievementTypes[ar.AchievementType]; ok {
+		achievement["achievementType"] = t
+	}
+
+	doc := map[string]interface{}{
+		"@context": openBadgeContext,
+		"id":       "urn:uuid:" + uuid.NewString(),
+		"type":     []string{"VerifiableCredential", "AchievementCredential"},
+		"name":     title,
+		"issuer": map[string]interface{}{
+			"id":   openBadgeIssuerID(),
+			"type": []string{"Profile"},
+			"name": utils.InstitutionName(),
+			"url":  utils.PublicBaseURL(),
+		},
+		"credentialSubject": map[string]interface{}{
+			"type": []string{"AchievementSubject"},
+			"identifier": []map[string]interface{}{
+				{"type": "IdentityObject", "identityType": "name", "hashed": false, "identityHash": subject.Name},
+				{"type": "IdentityObject", "identityType": "studentId", "hashed": false, "identityHash": subject.StudentID},
+			},
+			"achievement": achievement,
+		},
+	}
+	if ar.VerifiedAt != nil {
+		doc["validFrom"] = ar.VerifiedAt.UTC().Format(time.RFC3339)
+	}
+	return doc
+}
+
+// signOpenBadges builds and signs one credential per achievement.
+func (s *CredentialService) signOpenBadges(list []models.AchievementReference) ([]map[string]interface{}, error) {
+	key, err := s.signingKey()
+	if err != nil {
+		return nil, err
+	}
+	responses, err := s.Achievements.buildAchievementResponses(list, []string{"title", "description"}, nil)
+	if err != nil {
+		return nil, err
+	}
+	subjects := map[string]*models.CredentialSubject{}
+	out := make([]map[string]interface{}, 0, len(responses))
+	now := time.Now()
+	for _, ar := range responses {
+		subject, ok := subjects[ar.StudentID]
+		if !ok {
+			if subject, err = s.credentialSubject(ar.StudentID); err != nil {
+				return nil, err
+			}
+			subjects[ar.StudentID] = subject
+		}
+		doc := openBadge(ar, subject)
+		if err := credential.AddProof(doc, key, openBadgeIssuerID()+"#"+key.ID, now); err != nil {
+			return nil, err
+		}
+		out = append(out, doc)
+	}
+	return out, nil
+}
+
+// GET /api/v1/achievements/:id/openbadge
+func (s *CredentialService) OpenBadge(c *fiber.Ctx) error {
+	ar, actor, err := s.Achievements.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if !isVerifiedStatus(ar.Status) {
+		return c.Status(409).JSON(fiber.Map{"error": "only verified achievements can be exported"})
+	}
+	docs, err := s.signOpenBadges([]models.AchievementReference{*ar})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.AuditRepo.Log(actor.UserID, "credential.openbadge_export", "achievement", ar.ID, nil, c.IP()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "audit failed: " + err.Error()})
+	}
+	if c.QueryBool("download") {
+		c.Attachment("achievement-" + ar.ID + ".openbadge.json")
+	}
+	return c.JSON(docs[0], "application/ld+json")
+}
+
+// GET /api/v1/students/:id/openbadges
+// Semua prestasi terverifikasi mahasiswa, masing-masing kredensial sendiri.
+func (s *CredentialService) StudentOpenBadges(c *fiber.Ctx) error {
+	studentID := c.Params("id")
+	actor, err := s.Achievements.Policy.Actor(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := s.Achievements.Policy.Check(actor, studentID, ActionView); err != nil {
+		return respondPolicyError(c, err)
+	}
+	all, err := s.StudentRepo.FindAchievements(studentID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	verified := []models.AchievementReference{}
+	for _, ar := range all {
+		if isVerifiedStatus(ar.Status) {
+			verified = append(verified, ar)
+		}
+	}
+	docs, err := s.signOpenBadges(verified)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.AuditRepo.Log(actor.UserID, "credential.openbadge_export", "student", studentID, map[string]interface{}{
+		"count": len(docs),
+	}, c.IP()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "audit failed: " + err.Error()})
+	}
+	return c.JSON(fiber.Map{"data": docs})
+}
+
+// GET /api/v1/openbadges/issuer (publik)
+// Profil issuer dengan semua kunci (Multikey) untuk me-resolve verificationMethod.
+func (s *CredentialService) OpenBadgeIssuer(c *fiber.Ctx) error {
+	if _, err := s.signingKey(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "signing key unavailable"})
+	}
+	keys, err := s.Repo.FindAll()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "keys unavailable"})
+	}
+	issuer := openBadgeIssuerID()
+	methods := make([]fiber.Map, 0, len(keys))
+	ids := make([]string, 0, len(keys))
+	for _, k := range keys {
+		id := issuer + "#" + k.ID
+		methods = append(methods, fiber.Map{
+			"id":                 id,
+			"type":               "Multikey",
+			"controller":         issuer,
+			"publicKeyMultibase": credential.PublicKeyMultibase(k.Public),
+		})
+		ids = append(ids, id)
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(fiber.Map{
+		"@context":           append(append([]string{}, openBadgeContext...), "https://w3id.org/security/multikey/v1"),
+		"id":                 issuer,
+		"type":               []string{"Profile"},
+		"name":               utils.InstitutionName(),
+		"url":                utils.PublicBaseURL(),
+		"verificationMethod": methods,
+		"assertionMethod":    ids,
+	}, "application/ld+json")
+}
+
+// POST /api/v1/openbadges/verify (publik)
+// Body: kredensial Open Badges; memeriksa proof dengan kunci issuer.
+func (s *CredentialService) VerifyOpenBadge(c *fiber.Ctx) error {
+	var doc map[string]interface{}
+	if err := c.BodyParser(&doc); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+	set, err := s.jwks()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "keys unavailable"})
+	}
+	prefix := openBadgeIssuerID() + "#"
+	lookup := func(vm string) (ed25519.PublicKey, bool) {
+		if !strings.HasPrefix(vm, prefix) {
+			return nil, false
+		}
+		return set.Lookup(strings.TrimPrefix(vm, prefix))
+	}
+	proof, err := credential.VerifyProof(doc, lookup)
+	if err != nil {
+		return c.Status(422).JSON(fiber.Map{"valid": false, "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"valid": true, "verification_method": proof.VerificationMethod, "created": proof.Created})
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/Lutfania/ekrp/credential"
+	"github.com/Lutfania/ekrp/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CredentialService mengekspor prestasi terverifikasi sebagai kredensial JSON
+// yang ditandatangani Ed25519 dan memublikasikan kunci publiknya.
+type CredentialService struct {
+	Repo             *repository.CredentialKeyRepository
+	Achievements     *AchievementService
+	StudentRepo      *repository.StudentRepository
+	UserRepo         *repository.UserRepository
+	VerificationRepo *repository.VerificationRepository
+	AuditRepo        *repository.AuditRepository
+
+	mu     sync.Mutex
+	signer *credential.Key
+}
+
+func NewCredentialService(repo *repository.CredentialKeyRepository, achievements *AchievementService, students *repository.StudentRepository, users *repository.UserRepository, verifications *repository.VerificationRepository, audit *repository.AuditRepository) *CredentialService {
+	return &CredentialService{Repo: repo, Achievements: achievements, StudentRepo: students, UserRepo: users, VerificationRepo: verifications, AuditRepo: audit}
+}
+
+// credentialKeySecret reads CREDENTIAL_KEY_SECRET, the secret that seals
+// private keys in credential_keys. Wajib diisi dan tidak boleh sama dengan
+// JWT_SECRET.
+func credentialKeySecret() ([]byte, error) {
+	secret := os.Getenv("CREDENTIAL_KEY_SECRET")
+	if secret == "" {
+		return nil, errors.New("CREDENTIAL_KEY_SECRET is not set")
+	}
+	if secret == os.Getenv("JWT_SECRET") {
+		return nil, errors.New("CREDENTIAL_KEY_SECRET must differ from JWT_SECRET")
+	}
+	return []byte(secret), nil
+}
+
+// CheckCredentialKeySecret is called at startup so a missing sealing secret
+// fails fast instead of on the first export.
+func CheckCredentialKeySecret() error {
+	_, err := credentialKeySecret()
+	return err
+}
+
+// signingKey returns the active key, membuat kunci pertama bila belum ada.
+// Kunci yang di-cache hanya dipakai selama kid-nya masih kunci aktif di
+// database (rotasi bisa dilakukan instance lain).
+func (s *CredentialService) signingKey() (*credential.Key, error) {
+	secret, err := credentialKeySecret()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kid, err := s.Repo.ActiveID()
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil && s.signer != nil && s.signer.ID == kid {
+		return s.signer, nil
+	}
+	s.signer = nil
+	for attempt := 0; attempt < 2; attempt++ {
+		k, sealed, err := s.Repo.Active()
+		if err == nil {
+			if k.Private, err = credential.OpenPrivateKey(sealed, secret); err != nil {
+				return nil, err
+			}
+			s.signer = k
+			return k, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		k, err = credential.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		if sealed, err = credential.SealPrivateKey(k.Private, secret); err != nil {
+			return nil, err
+		}
+		if created, err := s.Repo.CreateIfNone(k, sealed, ""); err != nil {
+			return nil, err
+		} else if created {
+			s.signer = k
+			return k, nil
+		}
+		// instance lain baru saja membuat kunci: baca ulang
+	}
+	return nil, errors.New("no active credential key")
+}
+
+// jwks returns every published key (aktif dan yang sudah dipensiunkan).
+func (s *CredentialService) jwks() (credential.JWKS, error) {
+	keys, err := s.Repo.FindAll()
+	if err != nil {
+		return credential.JWKS{}, err
+	}
+	set := credential.JWKS{Keys: make([]credential.JWK, 0, len(keys))}
+	for i := range keys {
+		set.Keys = append(set.Keys, keys[i].JWK())
+	}
+	return set, nil
+}
+
+// credentialSubject describes the student (students.id) a credential is about.
+func (s *CredentialService) credentialSubject(studentID string) (*models.CredentialSubject, error) {
+	st, err := s.StudentRepo.FindById(studentID)
+	if err != nil {
+		return nil, err
+	}
+	subject := &models.CredentialSubject{StudentID: st.StudentID, ProgramStudy: st.ProgramStudy}
+	if u, err := s.UserRepo.FindById(st.UserID); err == nil {
+		subject.Name = u.FullName
+	}
+	return subject, nil
+}
+
+// buildCredential maps a verified achievement to the credential payload.
+func (s *CredentialService) buildCredential(ar *models.AchievementReference, kid string) (*models.AchievementCredential, error) {
+	if !isVerifiedStatus(ar.Status) {
+		return nil, &PolicyError{Status: 409, Message: "only verified achievements can be exported"}
+	}
+	list, err := s.Achievements.buildAchievementResponses([]models.AchievementReference{*ar}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := s.credentialSubject(ar.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	cred := &models.AchievementCredential{
+		ID:          "urn:uuid:" + uuid.NewString(),
+		Type:        models.CredentialType,
+		Issuer:      models.CredentialIssuer{Name: utils.InstitutionName(), URL: utils.PublicBaseURL(), Kid: kid},
+		IssuedAt:    time.Now().UTC().Truncate(time.Second),
+		Subject:     *subject,
+		Achievement: list[0],
+	}
+	if v, err := s.VerificationRepo.FindActive(ar.ID); err == nil {
+		cred.VerificationURL = utils.VerificationURL(v.Code)
+	}
+	return cred, nil
+}
+
+// Export -> GET /api/v1/achievements/:id/credential
+// Kredensial dalam JWS JSON (flattened); ?download=1 untuk disimpan sebagai file.
+func (s *CredentialService) Export(c *fiber.Ctx) error {
+	ar, actor, err := s.Achievements.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	key, err := s.signingKey()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "signing key unavailable: " + err.Error()})
+	}
+	cred, err := s.buildCredential(ar, key.ID)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	signed, err := credential.Sign(cred, key)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.AuditRepo.Log(actor.UserID, "credential.export", "achievement", ar.ID, map[string]interface{}{
+		"credential_id": cred.ID,
+		"kid":           key.ID,
+	}, c.IP()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "audit failed: " + err.Error()})
+	}
+	if c.QueryBool("download") {
+		c.Attachment("achievement-" + ar.ID + ".credential.json")
+	}
+	return c.JSON(signed)
+}
+
+// JWKS -> GET /.well-known/jwks.json (publik)
+func (s *CredentialService) JWKS(c *fiber.Ctx) error {
+	if _, err := s.signingKey(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "signing key unavailable"})
+	}
+	set, err := s.jwks()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "keys unavailable"})
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(set)
+}
+
+// Verify -> POST /api/v1/credentials/verify (publik)
+// Body: kredensial hasil Export. Hanya tanda tangan yang diperiksa, sama
+// seperti verifikasi offline dengan credential.Verify + JWKS.
+func (s *CredentialService) Verify(c *fiber.Ctx) error {
+	var signed credential.Signed
+	if err := c.BodyParser(&signed); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+	set, err := s.jwks()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "keys unavailable"})
+	}
+	payload, kid, err := credential.Verify(&signed, set.Lookup)
+	res := models.CredentialVerification{Kid: kid}
+	if err != nil {
+		res.Error = err.Error()
+		return c.Status(422).JSON(res)
+	}
+	var cred models.AchievementCredential
+	if err := json.Unmarshal(payload, &cred); err != nil {
+		res.Error = credential.ErrMalformed.Error()
+		return c.Status(422).JSON(res)
+	}
+	for _, k := range set.Keys {
+		if k.Kid == kid {
+			res.KeyRetired = k.RetiredAt != nil
+		}
+	}
+	res.Valid = true
+	res.Credential = &cred
+	return c.JSON(res)
+}
+
+// ListKeys -> GET /api/v1/credential-keys
+func (s *CredentialService) ListKeys(c *fiber.Ctx) error {
+	set, err := s.jwks()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"data": set.Keys})
+}
+
+// RotateKey -> POST /api/v1/credential-keys/rotate
+// Kunci lama dipensiunkan (tidak dipakai menandatangani lagi) tapi tetap
+// dipublikasikan, jadi kredensial yang sudah terbit tetap valid.
+func (s *CredentialService) RotateKey(c *fiber.Ctx) error {
+	actor, _ := c.Locals("user_id").(string)
+	k, err := credential.GenerateKey()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	secret, err := credentialKeySecret()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	sealed, err := credential.SealPrivateKey(k.Private, secret)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	s.mu.Lock()
+	err = s.Repo.Rotate(k, sealed, actor)
+	if err == nil {
+		s.signer = k
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := s.AuditRepo.Log(actor, "credential_key.rotate", "credential_key", k.ID, nil, c.IP()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "audit failed: " + err.Error()})
+	}
+	return c.Status(201).JSON(k.JWK())
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// EmailOutboxService lets admins inspect the email queue and resend failed
+// emails.
+type EmailOutboxService struct {
+	Repo  *repository.EmailOutboxRepository
+	Audit *repository.AuditRepository
+}
+
+func NewEmailOutboxService(repo *repository.EmailOutboxRepository, audit *repository.AuditRepository) *EmailOutboxService {
+	return &EmailOutboxService{Repo: repo, Audit: audit}
+}
+
+// GET /api/v1/email-outbox?status=pending|sent|failed&limit=&cursor=&sort=
+func (s *EmailOutboxService) List(c *fiber.Ctx) error {
+	f := models.OutboxFilter{Status: c.Query("status")}
+	switch f.Status {
+	case "", models.EmailPending, models.EmailSent, models.EmailFailed:
+	default:
+		return respondValidationError(c, models.ValidationErrors{
+			"status": fmt.Sprintf("must be one of %s, %s, %s", models.EmailPending, models.EmailSent, models.EmailFailed),
+		})
+	}
+	page, err := s.Repo.FindPage(f, pageRequest(c))
+	if err != nil {
+		return respondPageError(c, err)
+	}
+	return c.JSON(page)
+}
+
+// POST /api/v1/email-outbox/:id/retry
+// Email berstatus failed dikembalikan ke antrean dengan jatah percobaan baru.
+func (s *EmailOutboxService) Retry(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "email not found"})
+	}
+	ok, err := s.Repo.Retry(id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{"error": "only failed emails can be retried"})
+	}
+	actor, _ := c.Locals("user_id").(string)
+	if err := s.Audit.Log(actor, "email.retry", "email_outbox", id, nil, c.IP()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "audit failed: " + err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "email queued"})
+}
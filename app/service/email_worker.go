@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"math/rand"
+	"net/mail"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/Lutfania/ekrp/mailer"
+)
+
+// Metrik email, terlihat di GET /api/v1/metrics (expvar).
+var (
+	emailsSent    = expvar.NewInt("emails_sent")
+	emailsRetried = expvar.NewInt("emails_retried")
+	emailsFailed  = expvar.NewInt("emails_failed")
+)
+
+const (
+	emailMaxAttempts = 8
+	emailBatchSize   = 20
+	// emailLease: email yang diambil tapi tidak selesai (worker mati) dicoba
+	// lagi setelah lease habis. drain hanya mengirim selama pengiriman masih
+	// bisa selesai sebelum lease habis (lihat drain).
+	emailLease       = 5 * time.Minute
+	emailSendTimeout = time.Minute
+)
+
+// emailBackoff is the delay after failed attempt n: 30s, 1m, 2m, ... maks 1 jam,
+// plus jitter hingga 20% supaya retry tidak serempak.
+func emailBackoff(attempt int) time.Duration {
+	d := 30 * time.Second << uint(attempt-1)
+	if attempt > 8 || d > time.Hour {
+		d = time.Hour
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// EmailWorker sends the emails queued in email_outbox. Error SMTP hanya
+// menjadwalkan ulang email; request API yang mengantrekannya sudah selesai.
+type EmailWorker struct {
+	Repo   *repository.EmailOutboxRepository
+	Sender mailer.Sender
+}
+
+func NewEmailWorker(repo *repository.EmailOutboxRepository, sender mailer.Sender) *EmailWorker {
+	return &EmailWorker{Repo: repo, Sender: sender}
+}
+
+// Start polls the outbox every interval.
+func (w *EmailWorker) Start(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			w.drain()
+		}
+	}()
+}
+
+// drain sends due emails until the queue has nothing due. An email is only
+// sent while the send can still finish within the lease of its batch; the
+// rest is released, otherwise another instance could claim and send it too.
+func (w *EmailWorker) drain() {
+	for {
+		claimed := time.Now()
+		batch, err := w.Repo.Claim(emailBatchSize, emailLease)
+		if err != nil {
+			log.Println("email: cannot claim outbox:", err)
+			return
+		}
+		for i, e := range batch {
+			if time.Since(claimed)+emailSendTimeout > emailLease {
+				ids := make([]string, 0, len(batch)-i)
+				for _, rest := range batch[i:] {
+					ids = append(ids, rest.ID)
+				}
+				if err := w.Repo.Release(ids); err != nil {
+					log.Println("email: cannot release unsent emails:", err)
+				}
+				return
+			}
+			w.send(e)
+		}
+		if len(batch) < emailBatchSize {
+			return
+		}
+	}
+}
+
+func (w *EmailWorker) send(e models.OutboxEmail) {
+	ctx, cancel := context.WithTimeout(context.Background(), emailSendTimeout)
+	defer cancel()
+	err := w.Sender.Send(ctx, mailer.Message{
+		To:      mail.Address{Name: e.ToName, Address: e.ToAddress},
+		Subject: e.Subject,
+		Text:    e.TextBody,
+		HTML:    e.HTMLBody,
+	})
+	if err == nil {
+		emailsSent.Add(1)
+		if err := w.Repo.MarkSent(e.ID); err != nil {
+			log.Println("email:", e.ID, err)
+		}
+		return
+	}
+
+	log.Printf("email: %s to %s failed (attempt %d): %v", e.ID, e.ToAddress, e.Attempts, err)
+	if e.Attempts >= emailMaxAttempts {
+		emailsFailed.Add(1)
+		err = w.Repo.MarkFailed(e.ID, err.Error())
+	} else {
+		emailsRetried.Add(1)
+		err = w.Repo.Reschedule(e.ID, time.Now().Add(emailBackoff(e.Attempts)), err.Error())
+	}
+	if err != nil {
+		log.Println("email:", e.ID, err)
+	}
+}
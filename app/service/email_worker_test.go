@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEmailBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{5, 8 * time.Minute},
+		{6, 16 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{9, time.Hour},
+		{20, time.Hour},
+		{100, time.Hour}, // shift sebesar ini meluap tanpa batas attempt
+	}
+	for _, tt := range tests {
+		// jitter acak, jadi ulangi beberapa kali
+		for i := 0; i < 50; i++ {
+			d := emailBackoff(tt.attempt)
+			if d < tt.base || d > tt.base+tt.base/5 {
+				t.Fatalf("emailBackoff(%d) = %v, want within [%v, %v]", tt.attempt, d, tt.base, tt.base+tt.base/5)
+			}
+		}
+	}
+}
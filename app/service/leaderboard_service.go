@@ -0,0 +1,228 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	leaderboardCacheTTL = 5 * time.Minute
+	// leaderboardCacheMax membatasi jumlah entri: key berasal dari query string,
+	// jadi tanpa batas siapa pun bisa membesarkan map dengan filter acak.
+	leaderboardCacheMax = 500
+)
+
+// leaderboardCache menyimpan hasil query leaderboard di memori. Dikosongkan
+// setiap ada perubahan yang mempengaruhi poin (verify, hitung ulang, opt-out).
+type leaderboardCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedLeaderboard
+}
+
+type cachedLeaderboard struct {
+	body    fiber.Map
+	expires time.Time
+}
+
+var leaderboards = &leaderboardCache{entries: map[string]cachedLeaderboard{}}
+
+func (c *leaderboardCache) get(key string) (fiber.Map, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.body, true
+}
+
+// put stores body under key. Entri kedaluwarsa dibuang setiap kali key baru
+// ditambahkan; bila cache masih penuh, entri yang paling cepat kedaluwarsa
+// (yang tertua) dikeluarkan.
+func (c *leaderboardCache) put(key string, body fiber.Map) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok {
+		var oldest string
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(c.entries) >= leaderboardCacheMax {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[key] = cachedLeaderboard{body: body, expires: now.Add(leaderboardCacheTTL)}
+}
+
+// invalidateLeaderboards drops every cached ranking.
+func invalidateLeaderboards() {
+	leaderboards.mu.Lock()
+	defer leaderboards.mu.Unlock()
+	leaderboards.entries = map[string]cachedLeaderboard{}
+}
+
+type LeaderboardService struct {
+	Repo      *repository.LeaderboardRepository
+	MongoRepo *repository.MongoAchievementRepository
+}
+
+func NewLeaderboardService(repo *repository.LeaderboardRepository, mongo *repository.MongoAchievementRepository) *LeaderboardService {
+	return &LeaderboardService{Repo: repo, MongoRepo: mongo}
+}
+
+// leaderboardFilter membaca ?program_study=&academic_year=&category= dan
+// periode ?period=2025-2026 (tahun akademik) atau ?from=&to= (YYYY-MM-DD, to inklusif).
+func leaderboardFilter(c *fiber.Ctx) (models.LeaderboardFilter, error) {
+	f := models.LeaderboardFilter{
+		ProgramStudy: c.Query("program_study"),
+		AcademicYear: c.Query("academic_year"),
+		Category:     c.Query("category"),
+	}
+	if f.Category != "" {
+		if _, ok := models.AchievementTypes[f.Category]; !ok {
+			return f, fmt.Errorf("unknown category %q", f.Category)
+		}
+	}
+	if period := c.Query("period"); period != "" {
+		from, to, err := models.AcademicYearRange(period)
+		if err != nil {
+			return f, err
+		}
+		f.From, f.To = &from, &to
+		return f, nil
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, fmt.Errorf("invalid from date")
+		}
+		f.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, fmt.Errorf("invalid to date")
+		}
+		t = t.AddDate(0, 0, 1)
+		f.To = &t
+	}
+	return f, nil
+}
+
+func filterKey(f models.LeaderboardFilter) string {
+	var from, to string
+	if f.From != nil {
+		from = f.From.Format(time.RFC3339)
+	}
+	if f.To != nil {
+		to = f.To.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s|%s|%s|%s|%s", f.ProgramStudy, f.AcademicYear, f.Category, from, to)
+}
+
+// attachTitles mengisi TopAchievement dari Mongo dengan satu query $in.
+func (s *LeaderboardService) attachTitles(entries []models.LeaderboardEntry) error {
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.TopMongoID != "" {
+			ids = append(ids, e.TopMongoID)
+		}
+	}
+	docs, err := s.MongoRepo.FindByIDHexes(ids, "title")
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		if doc, ok := docs[entries[i].TopMongoID]; ok {
+			title := doc.Title
+			entries[i].TopAchievement = &title
+		}
+	}
+	return nil
+}
+
+// GET /api/v1/leaderboards?program_study=&academic_year=&category=&period=&page=&limit=
+func (s *LeaderboardService) Ranking(c *fiber.Ctx) error {
+	f, err := leaderboardFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	key := fmt.Sprintf("ranking|%s|%d|%d", filterKey(f), page, limit)
+	if body, ok := leaderboards.get(key); ok {
+		return c.JSON(body)
+	}
+
+	entries, total, err := s.Repo.Ranking(f, limit, (page-1)*limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.attachTitles(entries); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	body := fiber.Map{
+		"data":  entries,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	}
+	leaderboards.put(key, body)
+	return c.JSON(body)
+}
+
+// GET /api/v1/leaderboards/by/:dimension?limit=10 (program_study | academic_year | category)
+// Top N mahasiswa untuk setiap nilai dimensi.
+func (s *LeaderboardService) TopByGroup(c *fiber.Ctx) error {
+	dim := c.Params("dimension")
+	if !repository.IsLeaderboardDimension(dim) {
+		return c.Status(400).JSON(fiber.Map{"error": "dimension must be program_study, academic_year or category"})
+	}
+	f, err := leaderboardFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	key := fmt.Sprintf("group|%s|%s|%d", dim, filterKey(f), limit)
+	if body, ok := leaderboards.get(key); ok {
+		return c.JSON(body)
+	}
+
+	entries, err := s.Repo.TopByGroup(dim, f, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.attachTitles(entries); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	groups := map[string][]models.LeaderboardEntry{}
+	for _, e := range entries {
+		groups[e.Group] = append(groups[e.Group], e)
+	}
+	body := fiber.Map{"dimension": dim, "limit": limit, "groups": groups}
+	leaderboards.put(key, body)
+	return c.JSON(body)
+}
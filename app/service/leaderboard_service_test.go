@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLeaderboardCacheBounded(t *testing.T) {
+	c := &leaderboardCache{entries: map[string]cachedLeaderboard{}}
+	for i := 0; i < leaderboardCacheMax; i++ {
+		c.put(fmt.Sprintf("key-%d", i), fiber.Map{"i": i})
+	}
+	// entri tertua dikeluarkan saat cache penuh
+	c.put("extra", fiber.Map{})
+	if len(c.entries) != leaderboardCacheMax {
+		t.Fatalf("cache has %d entries, want %d", len(c.entries), leaderboardCacheMax)
+	}
+	if _, ok := c.get("key-0"); ok {
+		t.Fatal("oldest entry was not evicted")
+	}
+	if _, ok := c.get("extra"); !ok {
+		t.Fatal("new entry missing")
+	}
+
+	// entri kedaluwarsa dibuang seluruhnya sebelum mengeluarkan yang masih berlaku
+	for k, e := range c.entries {
+		if k != "extra" {
+			e.expires = time.Now().Add(-time.Second)
+			c.entries[k] = e
+		}
+	}
+	c.put("fresh", fiber.Map{})
+	if len(c.entries) != 2 {
+		t.Fatalf("cache has %d entries after purging expired ones, want 2", len(c.entries))
+	}
+
+	// menimpa key yang sudah ada tidak mengeluarkan entri lain
+	c.put("fresh", fiber.Map{"v": 2})
+	if len(c.entries) != 2 {
+		t.Fatalf("overwrite changed the size to %d", len(c.entries))
+	}
+}
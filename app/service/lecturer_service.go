@@ -7,20 +7,21 @@ import (
 )
 
 type LecturerService struct {
-	Repo *repository.LecturerRepository
+	Repo   *repository.LecturerRepository
+	Policy *AchievementPolicy
 }
 
-func NewLecturerService(repo *repository.LecturerRepository) *LecturerService {
-	return &LecturerService{Repo: repo}
+func NewLecturerService(repo *repository.LecturerRepository, policy *AchievementPolicy) *LecturerService {
+	return &LecturerService{Repo: repo, Policy: policy}
 }
 
-// GET /api/v1/lecturers
+// GET /api/v1/lecturers?department=&limit=&cursor=&sort=&total=
 func (s *LecturerService) FindAll(c *fiber.Ctx) error {
-	list, err := s.Repo.FindAll()
+	page, err := s.Repo.FindPage(models.LecturerFilter{Department: c.Query("department")}, pageRequest(c))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return respondPageError(c, err)
 	}
-	return c.JSON(list)
+	return c.JSON(page)
 }
 
 // GET /api/v1/lecturers/:id
@@ -59,8 +60,16 @@ func (s *LecturerService) Create(c *fiber.Ctx) error {
 }
 
 // GET /api/v1/lecturers/:id/advisees
+// Dosen hanya boleh melihat mahasiswa bimbingannya sendiri; admin semua.
 func (s *LecturerService) FindAdvisees(c *fiber.Ctx) error {
 	id := c.Params("id")
+	actor, err := s.Policy.Actor(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if actor.Kind != ActorAdmin && (actor.Kind != ActorLecturer || actor.LecturerID != id) {
+		return respondPolicyError(c, forbidden("not your advisees"))
+	}
 	rows, err := s.Repo.FindAdvisees(id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
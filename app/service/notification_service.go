@@ -0,0 +1,125 @@
+package service
+
+import (
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/Lutfania/ekrp/mailer"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// NotificationService melayani notifikasi milik user yang sedang login;
+// notifikasinya sendiri dibuat oleh AchievementService (lihat notify).
+type NotificationService struct {
+	Repo *repository.NotificationRepository
+}
+
+func NewNotificationService(repo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{Repo: repo}
+}
+
+// GET /api/v1/notifications?unread=true&event=&limit=&cursor=
+func (s *NotificationService) List(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	event := c.Query("event")
+	if event != "" && !models.IsNotificationEvent(event) {
+		return c.Status(400).JSON(fiber.Map{"error": "unknown event", "allowed": models.NotificationEvents})
+	}
+	page, err := s.Repo.FindPage(models.NotificationFilter{
+		UserID: userID,
+		Unread: c.QueryBool("unread", false),
+		Event:  event,
+	}, pageRequest(c))
+	if err != nil {
+		return respondPageError(c, err)
+	}
+	return c.JSON(page)
+}
+
+// GET /api/v1/notifications/unread-count
+func (s *NotificationService) UnreadCount(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	n, err := s.Repo.UnreadCount(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"unread": n})
+}
+
+// POST /api/v1/notifications/:id/read
+func (s *NotificationService) MarkRead(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid notification id"})
+	}
+	ok, err := s.Repo.MarkRead(userID, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "notification not found"})
+	}
+	return c.JSON(fiber.Map{"message": "marked as read"})
+}
+
+// POST /api/v1/notifications/read-all
+func (s *NotificationService) MarkAllRead(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	n, err := s.Repo.MarkAllRead(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "marked as read", "count": n})
+}
+
+// preferences merges the stored rows with the defaults: semua event aktif di
+// aplikasi, email untuk event di models.EmailEvents.
+func (s *NotificationService) preferences(userID string) ([]models.NotificationPreference, error) {
+	stored, err := s.Repo.Preferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.NotificationPreference, 0, len(models.NotificationEvents))
+	for _, ev := range models.NotificationEvents {
+		p, ok := stored[ev]
+		if !ok {
+			p = models.NotificationPreference{Event: ev, InApp: true, Email: models.IsEmailEvent(ev)}
+		}
+		p.Email = p.Email && models.IsEmailEvent(ev)
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// GET /api/v1/notifications/preferences
+func (s *NotificationService) Preferences(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	prefs, err := s.preferences(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	lang, err := s.Repo.Language(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"language": lang, "preferences": prefs})
+}
+
+// PUT /api/v1/notifications/preferences
+// {"language": "en", "preferences": [{"event": "achievement.commented", "in_app": false}, {"event": "achievement.verified", "email": false}]}
+// Event dan field yang tidak disebut tidak berubah.
+func (s *NotificationService) UpdatePreferences(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+	if err := req.Validate(mailer.Languages); err != nil {
+		return respondValidationError(c, err)
+	}
+	if err := s.Repo.SetPreferences(userID, req.Language, req.Preferences); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return s.Preferences(c)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// pageRequest membaca ?limit=&cursor=&sort=-created_at,title&total=true.
+func pageRequest(c *fiber.Ctx) models.PageRequest {
+	limit := c.QueryInt("limit", models.DefaultPageLimit)
+	if limit < 1 {
+		limit = models.DefaultPageLimit
+	}
+	if limit > models.MaxPageLimit {
+		limit = models.MaxPageLimit
+	}
+	return models.PageRequest{
+		Limit:     limit,
+		Cursor:    c.Query("cursor"),
+		Sort:      models.ParseSort(c.Query("sort")),
+		WithTotal: c.QueryBool("total", false),
+	}
+}
+
+// dateRange reads ?<from>=&<to>= as YYYY-MM-DD; to is inclusive, so the
+// returned upper bound is the start of the following day.
+func dateRange(c *fiber.Ctx, fromKey, toKey string) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if v := c.Query(fromKey); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, nil, &models.PageError{Message: fmt.Sprintf("invalid %s date", fromKey)}
+		}
+		from = &t
+	}
+	if v := c.Query(toKey); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, nil, &models.PageError{Message: fmt.Sprintf("invalid %s date", toKey)}
+		}
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+	return from, to, nil
+}
+
+// splitList parses a comma separated query value ("draft,submitted").
+func splitList(raw string) []string {
+	var out []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// respondPageError maps invalid sort/cursor/filter input to 400.
+func respondPageError(c *fiber.Ctx, err error) error {
+	var pe *models.PageError
+	if errors.As(err, &pe) {
+		return c.Status(400).JSON(fiber.Map{"error": pe.Message})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
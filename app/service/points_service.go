@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// PointsService menghitung poin prestasi terverifikasi dari tipe, tingkat dan
+// peringkat memakai aturan poin aktif tahun akademik saat diverifikasi.
+type PointsService struct {
+	RuleRepo  *repository.PointRuleRepository
+	PGRepo    *repository.AchievementRepository
+	MongoRepo *repository.MongoAchievementRepository
+}
+
+func NewPointsService(ruleRepo *repository.PointRuleRepository, pg *repository.AchievementRepository, mongo *repository.MongoAchievementRepository) *PointsService {
+	return &PointsService{RuleRepo: ruleRepo, PGRepo: pg, MongoRepo: mongo}
+}
+
+// Compute returns the points for an achievement verified at verifiedAt and the
+// rule set used (nil when the academic year has no rules yet).
+func (s *PointsService) Compute(ar *models.AchievementReference, verifiedAt time.Time) (int, *string, error) {
+	set, err := s.RuleRepo.FindActive(models.AcademicYearOf(verifiedAt))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	var doc *models.MongoAchievement
+	if ar.MongoAchievementID != "" && ar.AchievementType != "" {
+		if doc, err = s.MongoRepo.FindByIDHex(ar.MongoAchievementID); err != nil {
+			return 0, nil, err
+		}
+	}
+	points, setID := pointsFor(set, ar, doc)
+	return points, setID, nil
+}
+
+// pointsFor scores ar with set (nil = tanpa aturan: 0 poin, tanpa rule set);
+// doc may be nil when the achievement has no document or type.
+func pointsFor(set *models.PointRuleSet, ar *models.AchievementReference, doc *models.MongoAchievement) (int, *string) {
+	if set == nil {
+		return 0, nil
+	}
+	if doc == nil || ar.AchievementType == "" {
+		return 0, &set.ID
+	}
+	level, _ := doc.Extra["level"].(string)
+	rank, _ := doc.Extra["rank"].(string)
+	points, _ := models.MatchPointRule(set.Rules, ar.AchievementType, level, rank)
+	return points, &set.ID
+}
+
+// recalculate re-scores every verified achievement of the academic year with
+// its active rule set and returns how many were updated. Dokumen Mongo dibaca
+// dengan satu query $in dan semua skor ditulis dalam satu transaksi.
+func (s *PointsService) recalculate(year string) (int, error) {
+	from, to, err := models.AcademicYearRange(year)
+	if err != nil {
+		return 0, err
+	}
+	set, err := s.RuleRepo.FindActive(year)
+	if errors.Is(err, pgx.ErrNoRows) {
+		set = nil
+	} else if err != nil {
+		return 0, err
+	}
+	list, err := s.PGRepo.ListVerifiedBetween(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	docs := map[string]*models.MongoAchievement{}
+	if set != nil {
+		ids := make([]string, 0, len(list))
+		for _, ar := range list {
+			if ar.MongoAchievementID != "" {
+				ids = append(ids, ar.MongoAchievementID)
+			}
+		}
+		if docs, err = s.MongoRepo.FindByIDHexes(ids, "extra.level", "extra.rank"); err != nil {
+			return 0, err
+		}
+	}
+	updates := make([]models.PointsUpdate, 0, len(list))
+	for i := range list {
+		points, setID := pointsFor(set, &list[i], docs[list[i].MongoAchievementID])
+		updates = append(updates, models.PointsUpdate{ID: list[i].ID, Points: points, RuleSetID: setID})
+	}
+	if err := s.PGRepo.SetPoints(updates); err != nil {
+		return 0, err
+	}
+	invalidateLeaderboards()
+	return len(updates), nil
+}
+
+// GET /api/v1/point-rules
+func (s *PointsService) FindAll(c *fiber.Ctx) error {
+	sets, err := s.RuleRepo.FindAll()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(sets)
+}
+
+// GET /api/v1/point-rules/active?academic_year=2025-2026 (default: tahun akademik sekarang)
+func (s *PointsService) FindActive(c *fiber.Ctx) error {
+	year := c.Query("academic_year", models.AcademicYearOf(time.Now()))
+	set, err := s.RuleRepo.FindActive(year)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "no point rules for " + year})
+	}
+	return c.JSON(set)
+}
+
+// GET /api/v1/point-rules/:id
+func (s *PointsService) FindById(c *fiber.Ctx) error {
+	set, err := s.RuleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "rule set not found"})
+	}
+	return c.JSON(set)
+}
+
+// POST /api/v1/point-rules
+// Menyimpan versi baru aturan untuk tahun akademik lalu menghitung ulang poinnya.
+func (s *PointsService) Create(c *fiber.Ctx) error {
+	var req models.CreatePointRuleSetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+	req.AcademicYear = strings.TrimSpace(req.AcademicYear)
+	if _, _, err := models.AcademicYearRange(req.AcademicYear); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := models.ValidatePointRules(req.Rules); err != nil {
+		return respondValidationError(c, err)
+	}
+
+	actor, _ := c.Locals("user_id").(string)
+	set, err := s.RuleRepo.CreateRuleSet(req.AcademicYear, req.Rules, actor)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updated, err := s.recalculate(req.AcademicYear)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "rules saved but recalculation failed: " + err.Error(), "rule_set": set})
+	}
+	return c.Status(201).JSON(fiber.Map{"rule_set": set, "recalculated": updated})
+}
+
+// POST /api/v1/point-rules/recalculate?academic_year=2025-2026
+func (s *PointsService) Recalculate(c *fiber.Ctx) error {
+	year := c.Query("academic_year")
+	if year == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "academic_year required"})
+	}
+	if _, _, err := models.AcademicYearRange(year); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	// tanpa aturan untuk tahun itu poin di-nol-kan (lihat recalculate)
+	updated, err := s.recalculate(year)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"academic_year": year, "recalculated": updated})
+}
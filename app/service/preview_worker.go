@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/Lutfania/ekrp/storage"
+	"github.com/Lutfania/ekrp/utils"
+)
+
+const previewMaxDim = 480
+
+// previewKey: preview disimpan di samping file asli,
+// achievements/<id>/<file_id>.pdf -> achievements/<id>/<file_id>.preview.jpg
+func previewKey(storageKey string) string {
+	return strings.TrimSuffix(storageKey, path.Ext(storageKey)) + ".preview.jpg"
+}
+
+// canPreview reports whether the worker can try to make a preview of contentType.
+func canPreview(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "application/pdf":
+		return true
+	}
+	return false
+}
+
+// PreviewWorker membuat thumbnail lampiran di background supaya upload tidak
+// menunggu proses resize.
+type PreviewWorker struct {
+	MongoRepo *repository.MongoAchievementRepository
+	Files     storage.Storage
+	jobs      chan models.PreviewJob
+}
+
+func NewPreviewWorker(mongo *repository.MongoAchievementRepository, files storage.Storage, queueSize int) *PreviewWorker {
+	return &PreviewWorker{MongoRepo: mongo, Files: files, jobs: make(chan models.PreviewJob, queueSize)}
+}
+
+// Start runs n workers and requeues files left pending by a previous run.
+func (w *PreviewWorker) Start(n int) {
+	for i := 0; i < n; i++ {
+		go func() {
+			for job := range w.jobs {
+				w.process(job)
+			}
+		}()
+	}
+	go func() {
+		jobs, err := w.MongoRepo.PendingPreviews()
+		if err != nil {
+			log.Println("preview: cannot load pending previews:", err)
+			return
+		}
+		for _, job := range jobs {
+			w.jobs <- job
+		}
+	}()
+}
+
+// Enqueue never blocks; bila antrean penuh file tetap "pending" dan diproses
+// saat aplikasi start berikutnya.
+func (w *PreviewWorker) Enqueue(job models.PreviewJob) {
+	select {
+	case w.jobs <- job:
+	default:
+		log.Println("preview: queue full, file", job.File.FileID, "stays pending")
+	}
+}
+
+func (w *PreviewWorker) process(job models.PreviewJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	status, key := models.PreviewFailed, ""
+	thumb, err := w.render(ctx, job.File)
+	switch {
+	case errors.Is(err, utils.ErrNoPreview):
+		status = models.PreviewUnavailable
+	case err != nil:
+		log.Println("preview:", job.File.FileID, err)
+	default:
+		key = previewKey(job.File.StorageKey)
+		if err := w.Files.Put(ctx, key, bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+			log.Println("preview:", job.File.FileID, err)
+			key = ""
+		} else {
+			status = models.PreviewReady
+		}
+	}
+
+	err = w.MongoRepo.SetFilePreview(job.MongoID, job.File.FileID, job.File.StorageKey, key, status)
+	if errors.Is(err, repository.ErrFileNotFound) && key != "" {
+		// file sudah dihapus/diganti selama diproses
+		_ = w.Files.Delete(ctx, key)
+	} else if err != nil {
+		log.Println("preview:", job.File.FileID, err)
+	}
+}
+
+func (w *PreviewWorker) render(ctx context.Context, f models.Attachment) ([]byte, error) {
+	if !canPreview(f.ContentType) {
+		return nil, utils.ErrNoPreview
+	}
+	body, err := w.Files.Get(ctx, f.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return utils.Thumbnail(body, f.ContentType, previewMaxDim)
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,expiration,termination"
+	tusContentType = "application/offset+octet-stream"
+)
+
+// ResumableUploadService implements tus 1.0 (core + creation, expiration and
+// termination) di /api/v1/achievements/:id/uploads. Potongan ditulis ke file
+// sementara di Dir; setelah lengkap file divalidasi dan dilampirkan lewat
+// AchievementService.attachFile, sama seperti upload multipart biasa.
+type ResumableUploadService struct {
+	Repo         ResumableUploadStore
+	Achievements *AchievementService
+	Dir          string
+	TTL          time.Duration
+	// Authorize resolves the achievement of the URL for a caller who may
+	// still change its attachments (default: authorizeUpload).
+	Authorize func(c *fiber.Ctx) (*models.AchievementReference, error)
+
+	locks sync.Map // upload id -> *sync.Mutex, satu PATCH per upload
+}
+
+// ResumableUploadStore is satisfied by repository.ResumableUploadRepository.
+type ResumableUploadStore interface {
+	Create(achievementID, userID, fileName string, length int64, expiresAt time.Time) (*models.ResumableUpload, error)
+	Find(id string) (*models.ResumableUpload, error)
+	Advance(id string, from, to int64, expiresAt time.Time) error
+	Delete(id string) error
+	DeleteExpired(now time.Time) ([]string, error)
+}
+
+// NewResumableUploadService reads TUS_UPLOAD_DIR (default <tmp>/ekrp-tus) and
+// TUS_EXPIRE_HOURS (default 24).
+func NewResumableUploadService(repo *repository.ResumableUploadRepository, achievements *AchievementService) *ResumableUploadService {
+	dir := os.Getenv("TUS_UPLOAD_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "ekrp-tus")
+	}
+	ttl := 24 * time.Hour
+	if h, err := strconv.Atoi(os.Getenv("TUS_EXPIRE_HOURS")); err == nil && h > 0 {
+		ttl = time.Duration(h) * time.Hour
+	}
+	s := &ResumableUploadService{Repo: repo, Achievements: achievements, Dir: dir, TTL: ttl}
+	s.Authorize = s.authorizeUpload
+	return s
+}
+
+func (s *ResumableUploadService) dataPath(id string) string {
+	return filepath.Join(s.Dir, id)
+}
+
+func (s *ResumableUploadService) lock(id string) func() {
+	m, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	mu := m.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (s *ResumableUploadService) discard(id string) {
+	_ = s.Repo.Delete(id)
+	_ = os.Remove(s.dataPath(id))
+	s.locks.Delete(id)
+}
+
+// StartJanitor removes expired uploads every interval, plus data files left
+// behind by uploads whose row is gone (mis. prestasinya dihapus).
+func (s *ResumableUploadService) StartJanitor(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			s.sweep()
+		}
+	}()
+}
+
+func (s *ResumableUploadService) sweep() {
+	ids, err := s.Repo.DeleteExpired(time.Now())
+	if err != nil {
+		log.Println("tus: cannot delete expired uploads:", err)
+		return
+	}
+	for _, id := range ids {
+		_ = os.Remove(s.dataPath(id))
+		s.locks.Delete(id)
+	}
+
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) < s.TTL {
+			continue
+		}
+		if _, err := s.Repo.Find(e.Name()); errors.Is(err, pgx.ErrNoRows) {
+			_ = os.Remove(s.dataPath(e.Name()))
+		}
+	}
+}
+
+// uploadExpires formats Upload-Expires as an RFC 7231 HTTP-date (GMT).
+func uploadExpires(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
+
+func tusHeaders(c *fiber.Ctx) {
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+}
+
+// checkTusVersion: semua request selain OPTIONS wajib Tus-Resumable: 1.0.0.
+func checkTusVersion(c *fiber.Ctx) error {
+	if c.Get("Tus-Resumable") != tusVersion {
+		c.Set("Tus-Version", tusVersion)
+		return &PolicyError{Status: 412, Message: "unsupported tus version, expected " + tusVersion}
+	}
+	return nil
+}
+
+// authorizeUpload checks that the caller may still change the attachments of
+// the achievement in the URL.
+func (s *ResumableUploadService) authorizeUpload(c *fiber.Ctx) (*models.AchievementReference, error) {
+	ar, _, err := s.Achievements.Policy.Authorize(c, c.Params("id"), ActionModify)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAttachmentsEditable(ar); err != nil {
+		return nil, err
+	}
+	return ar, nil
+}
+
+// findUpload loads the upload of the URL; hanya pembuatnya yang bisa melanjutkan.
+func (s *ResumableUploadService) findUpload(c *fiber.Ctx, ar *models.AchievementReference) (*models.ResumableUpload, error) {
+	id := c.Params("uploadId")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, &PolicyError{Status: 404, Message: "upload not found"}
+	}
+	u, err := s.Repo.Find(id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, &PolicyError{Status: 404, Message: "upload not found"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.AchievementID != ar.ID || u.UserID != c.Locals("user_id") {
+		return nil, &PolicyError{Status: 404, Message: "upload not found"}
+	}
+	if time.Now().After(u.ExpiresAt) {
+		s.discard(u.ID)
+		return nil, &PolicyError{Status: 410, Message: "upload expired"}
+	}
+	return u, nil
+}
+
+// Options -> OPTIONS /api/v1/achievements/:id/uploads
+func (s *ResumableUploadService) Options(c *fiber.Ctx) error {
+	ar, err := s.Authorize(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	p, err := s.Achievements.Uploads.PolicyFor(ar.AchievementType)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set("Tus-Version", tusVersion)
+	c.Set("Tus-Extension", tusExtensions)
+	c.Set("Tus-Max-Size", strconv.FormatInt(p.MaxSizeBytes, 10))
+	return c.SendStatus(204)
+}
+
+// Create -> POST /api/v1/achievements/:id/uploads
+// Header: Upload-Length (wajib), Upload-Metadata "filename <base64>".
+func (s *ResumableUploadService) Create(c *fiber.Ctx) error {
+	tusHeaders(c)
+	if err := checkTusVersion(c); err != nil {
+		return respondPolicyError(c, err)
+	}
+	ar, err := s.Authorize(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Upload-Length must be a positive integer (Upload-Defer-Length is not supported)"})
+	}
+	p, err := s.Achievements.Uploads.PolicyFor(ar.AchievementType)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if limit := min(p.MaxSizeBytes, config.MaxResumableUploadBytes()); length > limit {
+		return respondUploadError(c, reject(413, RejectSizeLimit, "file is %d bytes; the limit is %d bytes", length, limit))
+	}
+	meta, ok := models.ParseUploadMetadata(c.Get("Upload-Metadata"))
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid Upload-Metadata"})
+	}
+	fileName := meta["filename"]
+	if fileName == "" {
+		fileName = "upload"
+	}
+
+	uploader, _ := c.Locals("user_id").(string)
+	u, err := s.Repo.Create(ar.ID, uploader, fileName, length, time.Now().Add(s.TTL))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := os.MkdirAll(s.Dir, 0o750); err != nil {
+		s.discard(u.ID)
+		return c.Status(500).JSON(fiber.Map{"error": "cannot create upload: " + err.Error()})
+	}
+	f, err := os.OpenFile(s.dataPath(u.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		s.discard(u.ID)
+		return c.Status(500).JSON(fiber.Map{"error": "cannot create upload: " + err.Error()})
+	}
+	f.Close()
+
+	c.Location("/api/v1/achievements/" + ar.ID + "/uploads/" + u.ID)
+	c.Set("Upload-Expires", uploadExpires(u.ExpiresAt))
+	return c.SendStatus(201)
+}
+
+// Head -> HEAD /api/v1/achievements/:id/uploads/:uploadId
+func (s *ResumableUploadService) Head(c *fiber.Ctx) error {
+	tusHeaders(c)
+	if err := checkTusVersion(c); err != nil {
+		return respondPolicyError(c, err)
+	}
+	ar, err := s.Authorize(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	u, err := s.findUpload(c, ar)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	c.Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(u.Length, 10))
+	c.Set("Upload-Expires", uploadExpires(u.ExpiresAt))
+	return c.SendStatus(200)
+}
+
+// Patch -> PATCH /api/v1/achievements/:id/uploads/:uploadId
+// Body application/offset+octet-stream mulai di Upload-Offset. Potongan
+// terakhir melampirkan file; ID-nya dikirim di header Upload-File-Id.
+func (s *ResumableUploadService) Patch(c *fiber.Ctx) error {
+	tusHeaders(c)
+	if err := checkTusVersion(c); err != nil {
+		return respondPolicyError(c, err)
+	}
+	if c.Get(fiber.HeaderContentType) != tusContentType {
+		return c.Status(415).JSON(fiber.Map{"error": "Content-Type must be " + tusContentType})
+	}
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Upload-Offset must be a non-negative integer"})
+	}
+	ar, err := s.Authorize(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+
+	unlock := s.lock(c.Params("uploadId"))
+	defer unlock()
+	u, err := s.findUpload(c, ar)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if offset != u.Offset {
+		c.Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+		return c.Status(409).JSON(fiber.Map{"error": "Upload-Offset does not match", "offset": u.Offset})
+	}
+	chunk := c.Body()
+	if offset+int64(len(chunk)) > u.Length {
+		return c.Status(413).JSON(fiber.Map{"error": "chunk exceeds Upload-Length"})
+	}
+
+	if err := writeChunk(s.dataPath(u.ID), offset, chunk); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "cannot write chunk: " + err.Error()})
+	}
+	newOffset := offset + int64(len(chunk))
+	expires := time.Now().Add(s.TTL)
+	if err := s.Repo.Advance(u.ID, offset, newOffset, expires); err != nil {
+		if errors.Is(err, repository.ErrUploadOffsetMismatch) {
+			return c.Status(409).JSON(fiber.Map{"error": "Upload-Offset does not match"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set("Upload-Offset", strconv.FormatInt(newOffset, 10))
+	c.Set("Upload-Expires", uploadExpires(expires))
+	if newOffset < u.Length {
+		return c.SendStatus(204)
+	}
+
+	meta, err := s.complete(ar, u)
+	if err != nil {
+		return respondUploadError(c, err)
+	}
+	c.Set("Upload-File-Id", meta.FileID)
+	return c.SendStatus(204)
+}
+
+// writeChunk writes chunk at offset, memotong sisa tulisan gagal sebelumnya.
+func writeChunk(path string, offset int64, chunk []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(offset); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.WriteAt(chunk, offset); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// complete validates the assembled file and attaches it to the achievement.
+// Upload dibuang apa pun hasilnya: file yang ditolak tidak bisa dilanjutkan.
+func (s *ResumableUploadService) complete(ar *models.AchievementReference, u *models.ResumableUpload) (*models.Attachment, error) {
+	defer s.discard(u.ID)
+
+	f, err := os.Open(s.dataPath(u.ID))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	contentType, err := s.Achievements.Uploads.Validate(ar.AchievementType, u.Length, f)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := s.Achievements.attachFile(ar, u.FileName, contentType, u.Length, io.LimitReader(f, u.Length), u.UserID)
+	if err != nil {
+		return nil, err
+	}
+	uploadsAccepted.Add(1)
+	return meta, nil
+}
+
+// Terminate -> DELETE /api/v1/achievements/:id/uploads/:uploadId
+func (s *ResumableUploadService) Terminate(c *fiber.Ctx) error {
+	tusHeaders(c)
+	if err := checkTusVersion(c); err != nil {
+		return respondPolicyError(c, err)
+	}
+	ar, err := s.Authorize(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	unlock := s.lock(c.Params("uploadId"))
+	defer unlock()
+	u, err := s.findUpload(c, ar)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	s.discard(u.ID)
+	return c.SendStatus(204)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// memUploadStore is an in-memory ResumableUploadStore.
+type memUploadStore struct {
+	mu      sync.Mutex
+	uploads map[string]models.ResumableUpload
+}
+
+func (m *memUploadStore) Create(achievementID, userID, fileName string, length int64, expiresAt time.Time) (*models.ResumableUpload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u := models.ResumableUpload{ID: uuid.NewString(), AchievementID: achievementID, UserID: userID, FileName: fileName, Length: length, ExpiresAt: expiresAt, CreatedAt: time.Now()}
+	m.uploads[u.ID] = u
+	return &u, nil
+}
+
+func (m *memUploadStore) Find(id string) (*models.ResumableUpload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.uploads[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &u, nil
+}
+
+func (m *memUploadStore) Advance(id string, from, to int64, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.uploads[id]
+	if !ok || u.Offset != from {
+		return repository.ErrUploadOffsetMismatch
+	}
+	u.Offset, u.ExpiresAt = to, expiresAt
+	m.uploads[id] = u
+	return nil
+}
+
+func (m *memUploadStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.uploads, id)
+	return nil
+}
+
+func (m *memUploadStore) DeleteExpired(now time.Time) ([]string, error) {
+	return nil, nil
+}
+
+const (
+	tusTestAchievement = "11111111-1111-1111-1111-111111111111"
+	tusTestUser        = "22222222-2222-2222-2222-222222222222"
+)
+
+// newTusTestApp mounts the tus routes with an in-memory store and an
+// authorizer that always allows tusTestUser on tusTestAchievement.
+func newTusTestApp(t *testing.T) (*fiber.App, *ResumableUploadService, *memUploadStore) {
+	t.Helper()
+	store := &memUploadStore{uploads: map[string]models.ResumableUpload{}}
+	s := &ResumableUploadService{Repo: store, Dir: t.TempDir(), TTL: time.Hour}
+	s.Authorize = func(c *fiber.Ctx) (*models.AchievementReference, error) {
+		return &models.AchievementReference{ID: c.Params("id"), Status: "draft"}, nil
+	}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", tusTestUser)
+		return c.Next()
+	})
+	app.Head("/achievements/:id/uploads/:uploadId", s.Head)
+	app.Patch("/achievements/:id/uploads/:uploadId", s.Patch)
+	app.Delete("/achievements/:id/uploads/:uploadId", s.Terminate)
+	return app, s, store
+}
+
+// seedUpload stores an upload with its (empty) data file.
+func seedUpload(t *testing.T, s *ResumableUploadService, store *memUploadStore, length int64, expiresAt time.Time) string {
+	t.Helper()
+	u, err := store.Create(tusTestAchievement, tusTestUser, "sertifikat.pdf", length, expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.dataPath(u.ID), nil, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	return u.ID
+}
+
+func tusRequest(method, id, body string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(method, "/achievements/"+tusTestAchievement+"/uploads/"+id, strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", tusVersion)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestResumableUploadPatchOffset(t *testing.T) {
+	app, s, store := newTusTestApp(t)
+	id := seedUpload(t, s, store, 10, time.Now().Add(time.Hour))
+	patch := func(offset, body string) *http.Response {
+		resp, err := app.Test(tusRequest(fiber.MethodPatch, id, body, map[string]string{
+			"Content-Type":  tusContentType,
+			"Upload-Offset": offset,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := patch("0", "abcd")
+	if resp.StatusCode != 204 {
+		t.Fatalf("first chunk: status %d, want 204", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Upload-Offset"); got != "4" {
+		t.Fatalf("Upload-Offset = %q, want 4", got)
+	}
+	expires := resp.Header.Get("Upload-Expires")
+	if _, err := time.Parse(http.TimeFormat, expires); err != nil || !strings.HasSuffix(expires, " GMT") {
+		t.Fatalf("Upload-Expires = %q, want an HTTP-date in GMT", expires)
+	}
+
+	// potongan yang sama dikirim ulang: offset sudah 4
+	resp = patch("0", "abcd")
+	if resp.StatusCode != 409 {
+		t.Fatalf("stale offset: status %d, want 409", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Upload-Offset"); got != "4" {
+		t.Fatalf("409 Upload-Offset = %q, want 4", got)
+	}
+
+	data, err := os.ReadFile(s.dataPath(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "abcd" {
+		t.Fatalf("data file = %q, want %q", data, "abcd")
+	}
+}
+
+func TestResumableUploadHead(t *testing.T) {
+	app, s, store := newTusTestApp(t)
+	// zona waktu server tidak boleh terbawa ke header
+	id := seedUpload(t, s, store, 10, time.Date(2126, 10, 18, 7, 30, 0, 0, time.FixedZone("WIB", 7*3600)))
+
+	resp, err := app.Test(tusRequest(fiber.MethodHead, id, "", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+	if got, want := resp.Header.Get("Upload-Expires"), "Fri, 18 Oct 2126 00:30:00 GMT"; got != want {
+		t.Fatalf("Upload-Expires = %q, want %q", got, want)
+	}
+	if got := resp.Header.Get("Upload-Length"); got != "10" {
+		t.Fatalf("Upload-Length = %q, want 10", got)
+	}
+}
+
+func TestResumableUploadExpired(t *testing.T) {
+	for _, method := range []string{fiber.MethodHead, fiber.MethodPatch} {
+		t.Run(method, func(t *testing.T) {
+			app, s, store := newTusTestApp(t)
+			id := seedUpload(t, s, store, 10, time.Now().Add(-time.Minute))
+
+			resp, err := app.Test(tusRequest(method, id, "abcd", map[string]string{
+				"Content-Type":  tusContentType,
+				"Upload-Offset": "0",
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != 410 {
+				t.Fatalf("status %d, want 410", resp.StatusCode)
+			}
+			if _, err := store.Find(id); err == nil {
+				t.Fatal("expired upload was not discarded")
+			}
+			if _, err := os.Stat(s.dataPath(id)); !os.IsNotExist(err) {
+				t.Fatalf("data file still exists: %v", err)
+			}
+		})
+	}
+}
+
+func TestResumableUploadTerminate(t *testing.T) {
+	app, s, store := newTusTestApp(t)
+	id := seedUpload(t, s, store, 10, time.Now().Add(time.Hour))
+
+	resp, err := app.Test(tusRequest(fiber.MethodDelete, id, "", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 204 {
+		t.Fatalf("status %d, want 204", resp.StatusCode)
+	}
+	if _, err := os.Stat(s.dataPath(id)); !os.IsNotExist(err) {
+		t.Fatalf("data file still exists: %v", err)
+	}
+
+	// upload yang sudah dihentikan tidak bisa dilanjutkan
+	resp, err = app.Test(tusRequest(fiber.MethodHead, id, "", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 404 {
+		t.Fatalf("HEAD after termination: status %d, want 404", resp.StatusCode)
+	}
+
+	resp, err = app.Test(tusRequest(fiber.MethodDelete, id, "", map[string]string{"Tus-Resumable": "0.2.2"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 412 {
+		t.Fatalf("wrong Tus-Resumable: status %d, want 412", resp.StatusCode)
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RoleService mengelola roles, permissions dan role_permissions. Setiap perubahan
+// dicatat di audit_logs dalam transaksi yang sama, dan token milik pemegang role
+// dicabut supaya permission baru langsung berlaku (client cukup refresh token).
+type RoleService struct {
+	RoleRepo       *repository.RoleRepository
+	PermissionRepo *repository.PermissionRepository
+	AuditRepo      *repository.AuditRepository
+}
+
+func NewRoleService(roleRepo *repository.RoleRepository, permRepo *repository.PermissionRepository, auditRepo *repository.AuditRepository) *RoleService {
+	return &RoleService{
+		RoleRepo:       roleRepo,
+		PermissionRepo: permRepo,
+		AuditRepo:      auditRepo,
+	}
+}
+
+// GET /api/v1/roles
+func (s *RoleService) FindAll(c *fiber.Ctx) error {
+	roles, err := s.RoleRepo.FindAll()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(roles)
+}
+
+// GET /api/v1/roles/:id
+func (s *RoleService) FindById(c *fiber.Ctx) error {
+	role, err := s.RoleRepo.FindById(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+	perms, err := s.PermissionRepo.ListByRole(role.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"role": role, "permissions": perms})
+}
+
+// POST /api/v1/roles
+func (s *RoleService) Create(c *fiber.Ctx) error {
+	var req models.RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "name required"})
+	}
+
+	role, err := s.RoleRepo.Create(&req, s.audit(c, "role.create", "", map[string]interface{}{"name": req.Name}))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(role)
+}
+
+// PUT /api/v1/roles/:id
+func (s *RoleService) Update(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req models.RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "name required"})
+	}
+
+	old, err := s.RoleRepo.FindById(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+	audit := s.audit(c, "role.update", id, map[string]interface{}{"old_name": old.Name, "new_name": req.Name})
+	if err := s.RoleRepo.Update(id, &req, audit); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "role updated"})
+}
+
+// DELETE /api/v1/roles/:id
+func (s *RoleService) Delete(c *fiber.Ctx) error {
+	id := c.Params("id")
+	role, err := s.RoleRepo.FindById(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+
+	n, err := s.RoleRepo.CountUsers(id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if n > 0 {
+		return c.Status(409).JSON(fiber.Map{"error": "role is still assigned to users", "users": n})
+	}
+
+	if err := s.RoleRepo.Delete(id, s.audit(c, "role.delete", id, map[string]interface{}{"name": role.Name})); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "role deleted"})
+}
+
+// GET /api/v1/roles/:id/users
+func (s *RoleService) FindUsers(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := s.RoleRepo.FindById(id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+	users, err := s.RoleRepo.FindUsers(id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(users)
+}
+
+// GET /api/v1/roles/:id/permissions
+func (s *RoleService) FindPermissions(c *fiber.Ctx) error {
+	perms, err := s.PermissionRepo.ListByRole(c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(perms)
+}
+
+// POST /api/v1/roles/:id/permissions
+func (s *RoleService) AttachPermission(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var req models.AttachPermissionRequest
+	if err := c.BodyParser(&req); err != nil || req.PermissionID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "permission_id required"})
+	}
+
+	if _, err := s.RoleRepo.FindById(id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "role not found"})
+	}
+	perm, err := s.PermissionRepo.FindPermissionByID(req.PermissionID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "permission not found"})
+	}
+
+	added, err := s.PermissionRepo.AttachToRole(id, perm.ID, s.permissionAudit(c, "role.permission.attach", id, perm))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !added {
+		return c.JSON(fiber.Map{"message": "permission already attached"})
+	}
+	return c.JSON(fiber.Map{"message": "permission attached"})
+}
+
+// DELETE /api/v1/roles/:id/permissions/:permissionId
+func (s *RoleService) DetachPermission(c *fiber.Ctx) error {
+	id := c.Params("id")
+	perm, err := s.PermissionRepo.FindPermissionByID(c.Params("permissionId"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "permission not found"})
+	}
+
+	removed, err := s.PermissionRepo.DetachFromRole(id, perm.ID, s.permissionAudit(c, "role.permission.detach", id, perm))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !removed {
+		return c.Status(404).JSON(fiber.Map{"error": "permission not attached to role"})
+	}
+	return c.JSON(fiber.Map{"message": "permission detached"})
+}
+
+// GET /api/v1/permissions
+func (s *RoleService) FindAllPermissions(c *fiber.Ctx) error {
+	perms, err := s.PermissionRepo.ListPermissions()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(perms)
+}
+
+// GET /api/v1/roles/audit
+func (s *RoleService) AuditLog(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 100)
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	logs, err := s.AuditRepo.ListByEntityType("role", limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(logs)
+}
+
+// permissionAudit is the audit entry of a permission change; repository
+// menulisnya bersama perubahan dan pencabutan token pemegang role.
+func (s *RoleService) permissionAudit(c *fiber.Ctx, action, roleID string, perm *models.Permission) models.AuditEntry {
+	return s.audit(c, action, roleID, map[string]interface{}{
+		"permission_id":   perm.ID,
+		"permission_name": perm.Name,
+	})
+}
+
+// audit builds the entry the repository writes in the change's transaction.
+func (s *RoleService) audit(c *fiber.Ctx, action, roleID string, details map[string]interface{}) models.AuditEntry {
+	actor, _ := c.Locals("user_id").(string)
+	return models.AuditEntry{ActorID: actor, Action: action, EntityType: "role", EntityID: roleID, Details: details, IP: c.IP()}
+}
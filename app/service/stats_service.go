@@ -0,0 +1,161 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// StatsService menyusun dashboard statistik prestasi. Isinya disesuaikan
+// dengan peran: mahasiswa melihat miliknya, dosen wali melihat mahasiswa
+// bimbingannya, admin melihat semuanya (termasuk rekap per dosen wali).
+type StatsService struct {
+	Repo   *repository.StatsRepository
+	Policy *AchievementPolicy
+}
+
+func NewStatsService(repo *repository.StatsRepository, policy *AchievementPolicy) *StatsService {
+	return &StatsService{Repo: repo, Policy: policy}
+}
+
+// statsFilter resolves the caller's scope plus ?from=&to= (YYYY-MM-DD, to inklusif).
+func (s *StatsService) statsFilter(c *fiber.Ctx) (*Actor, models.StatsFilter, error) {
+	actor, err := s.Policy.Actor(c)
+	if err != nil {
+		return nil, models.StatsFilter{}, err
+	}
+	scope, err := s.Policy.ScopeFilter(actor, models.AchievementFilter{StudentID: c.Query("student_id")})
+	if err != nil {
+		return nil, models.StatsFilter{}, err
+	}
+	f := models.StatsFilter{StudentID: scope.StudentID, AdvisorID: scope.AdvisorID}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, f, &PolicyError{Status: 400, Message: "invalid from date"}
+		}
+		f.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, f, &PolicyError{Status: 400, Message: "invalid to date"}
+		}
+		t = t.AddDate(0, 0, 1)
+		f.To = &t
+	}
+	return actor, f, nil
+}
+
+func scopeName(a *Actor) string {
+	switch a.Kind {
+	case ActorAdmin:
+		return "admin"
+	case ActorLecturer:
+		return "advisees"
+	}
+	return "own"
+}
+
+// GET /api/v1/stats?from=&to=&interval=month (day | week | month)
+func (s *StatsService) Dashboard(c *fiber.Ctx) error {
+	actor, f, err := s.statsFilter(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	interval := c.Query("interval", "month")
+	if !models.IsStatsInterval(interval) {
+		return c.Status(400).JSON(fiber.Map{"error": "interval must be day, week or month"})
+	}
+
+	byStatus, err := s.Repo.CountByStatus(f)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	byType, err := s.Repo.CountByType(f)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	statusTimeline, err := s.Repo.Timeline(f, interval, "status")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	typeTimeline, err := s.Repo.Timeline(f, interval, "type")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	verification, err := s.Repo.VerificationTime(f)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// tingkat hanya ada di dokumen Mongo (extra.level)
+	levelTimeline, err := s.Repo.LevelTimeline(f, interval)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	byLevel := map[string]int{}
+	for _, b := range levelTimeline {
+		byLevel[b.Key] += b.Count
+	}
+
+	body := fiber.Map{
+		"scope": scopeName(actor),
+		"totals": fiber.Map{
+			"by_status": byStatus,
+			"by_type":   byType,
+			"by_level":  byLevel,
+		},
+		"timeline": fiber.Map{
+			"interval":  interval,
+			"by_status": statusTimeline,
+			"by_type":   typeTimeline,
+			"by_level":  levelTimeline,
+		},
+		"verification_time": verification,
+	}
+
+	if actor.Kind != ActorStudent {
+		byProgram, err := s.Repo.ByProgramStudy(f)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		body["by_program_study"] = byProgram
+	}
+	if actor.Kind == ActorAdmin {
+		byAdvisor, err := s.Repo.ByAdvisor(f)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		body["by_advisor"] = byAdvisor
+	}
+	return c.JSON(body)
+}
+
+// GET /api/v1/stats/program-studies?from=&to=
+func (s *StatsService) ProgramStudies(c *fiber.Ctx) error {
+	_, f, err := s.statsFilter(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	out, err := s.Repo.ByProgramStudy(f)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(out)
+}
+
+// GET /api/v1/stats/advisors?from=&to= (admin)
+func (s *StatsService) Advisors(c *fiber.Ctx) error {
+	_, f, err := s.statsFilter(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	out, err := s.Repo.ByAdvisor(f)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(out)
+}
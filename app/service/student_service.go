@@ -8,19 +8,25 @@ import (
 )
 
 type StudentService struct {
-	Repo *repository.StudentRepository
+	Repo   *repository.StudentRepository
+	Policy *AchievementPolicy
 }
 
-func NewStudentService(repo *repository.StudentRepository) *StudentService {
-	return &StudentService{Repo: repo}
+func NewStudentService(repo *repository.StudentRepository, policy *AchievementPolicy) *StudentService {
+	return &StudentService{Repo: repo, Policy: policy}
 }
 
+// GET /api/v1/students?program_study=&academic_year=&advisor_id=&limit=&cursor=&sort=&total=
 func (s *StudentService) FindAll(c *fiber.Ctx) error {
-	list, err := s.Repo.FindAll()
+	page, err := s.Repo.FindPage(models.StudentFilter{
+		ProgramStudy: c.Query("program_study"),
+		AcademicYear: c.Query("academic_year"),
+		AdvisorID:    c.Query("advisor_id"),
+	}, pageRequest(c))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return respondPageError(c, err)
 	}
-	return c.JSON(list)
+	return c.JSON(page)
 }
 
 func (s *StudentService) FindById(c *fiber.Ctx) error {
@@ -63,9 +69,64 @@ func (s *StudentService) UpdateAdvisor(c *fiber.Ctx) error {
 
 func (s *StudentService) FindAchievements(c *fiber.Ctx) error {
 	id := c.Params("id")
+	actor, err := s.Policy.Actor(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := s.Policy.Check(actor, id, ActionView); err != nil {
+		return respondPolicyError(c, err)
+	}
 	list, err := s.Repo.FindAchievements(id)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(list)
 }
+
+// GET /api/v1/students/:id/points
+func (s *StudentService) Points(c *fiber.Ctx) error {
+	id := c.Params("id")
+	actor, err := s.Policy.Actor(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := s.Policy.Check(actor, id, ActionView); err != nil {
+		return respondPolicyError(c, err)
+	}
+
+	st, err := s.Repo.FindById(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "student not found"})
+	}
+	byType, err := s.Repo.PointsByType(id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"student_id":   st.ID,
+		"total_points": st.TotalPoints,
+		"by_type":      byType,
+	})
+}
+
+// PUT /api/v1/students/:id/listing {"public_listing": false}
+// Mahasiswa (atau admin) memilih tampil / tidak di leaderboard publik.
+func (s *StudentService) UpdateListing(c *fiber.Ctx) error {
+	id := c.Params("id")
+	actor, err := s.Policy.Actor(c)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if err := s.Policy.Check(actor, id, ActionModify); err != nil {
+		return respondPolicyError(c, err)
+	}
+	var req models.UpdateListingRequest
+	if err := c.BodyParser(&req); err != nil || req.PublicListing == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "public_listing required"})
+	}
+	if err := s.Repo.SetPublicListing(id, *req.PublicListing); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "student not found"})
+	}
+	invalidateLeaderboards()
+	return c.JSON(fiber.Map{"message": "listing updated", "public_listing": *req.PublicListing})
+}
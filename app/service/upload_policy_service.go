@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/Lutfania/ekrp/scanner"
+	"github.com/Lutfania/ekrp/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// Metrik upload, terlihat di GET /api/v1/metrics (expvar).
+var (
+	uploadRejections = expvar.NewMap("upload_rejections") // per alasan
+	uploadsAccepted  = expvar.NewInt("uploads_accepted")
+)
+
+// Alasan penolakan upload.
+const (
+	RejectSizeLimit       = "size_limit"
+	RejectTypeNotAllowed  = "type_not_allowed"
+	RejectMalwareDetected = "malware_detected"
+	RejectScanUnavailable = "scan_unavailable"
+)
+
+// UploadRejection is returned by Validate when a file fails a check.
+type UploadRejection struct {
+	Status  int
+	Reason  string
+	Message string
+}
+
+func (e *UploadRejection) Error() string { return e.Message }
+
+func reject(status int, reason, format string, args ...interface{}) *UploadRejection {
+	uploadRejections.Add(reason, 1)
+	return &UploadRejection{Status: status, Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+func respondUploadError(c *fiber.Ctx, err error) error {
+	var re *UploadRejection
+	if errors.As(err, &re) {
+		return c.Status(re.Status).JSON(fiber.Map{"error": re.Message, "reason": re.Reason})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
+
+// UploadPolicyService memvalidasi lampiran (ukuran, tipe dari magic bytes,
+// scan malware) sesuai kebijakan per tipe prestasi yang diatur admin.
+type UploadPolicyService struct {
+	Repo      *repository.UploadPolicyRepository
+	AuditRepo *repository.AuditRepository
+	Scanner   scanner.Scanner
+}
+
+func NewUploadPolicyService(repo *repository.UploadPolicyRepository, audit *repository.AuditRepository, sc scanner.Scanner) *UploadPolicyService {
+	return &UploadPolicyService{Repo: repo, AuditRepo: audit, Scanner: sc}
+}
+
+// MaxFileSize is the largest file that can be accepted at all: batas upload
+// resumable, dan batas stream scanner bila aktif (file yang lebih besar
+// tidak akan pernah lolos scan).
+func (s *UploadPolicyService) MaxFileSize() int64 {
+	max := config.MaxResumableUploadBytes()
+	if sm := s.Scanner.MaxSize(); sm > 0 && sm < max {
+		max = sm
+	}
+	return max
+}
+
+// PolicyFor returns the policy of the type, else "default", else the builtin
+// one, with MaxSizeBytes capped at MaxFileSize.
+func (s *UploadPolicyService) PolicyFor(achievementType string) (*models.UploadPolicy, error) {
+	p, err := s.findPolicy(achievementType)
+	if err != nil {
+		return nil, err
+	}
+	p.MaxSizeBytes = min(p.MaxSizeBytes, s.MaxFileSize())
+	return p, nil
+}
+
+func (s *UploadPolicyService) findPolicy(achievementType string) (*models.UploadPolicy, error) {
+	for _, key := range []string{achievementType, models.DefaultUploadPolicyKey} {
+		if key == "" {
+			continue
+		}
+		p, err := s.Repo.Find(key)
+		if err == nil {
+			return p, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+	}
+	p := models.BuiltinUploadPolicy(achievementType)
+	return &p, nil
+}
+
+// Validate checks a file of size bytes for an achievement of achievementType
+// and returns its sniffed content type. f is rewound before returning.
+func (s *UploadPolicyService) Validate(achievementType string, size int64, f io.ReadSeeker) (string, error) {
+	p, err := s.PolicyFor(achievementType)
+	if err != nil {
+		return "", err
+	}
+	if size > p.MaxSizeBytes {
+		return "", reject(413, RejectSizeLimit, "file is %d bytes; the limit is %d bytes", size, p.MaxSizeBytes)
+	}
+
+	// tipe file dari isinya, bukan dari header Content-Type klien
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	contentType := utils.SniffContentType(head[:n])
+	allowed := false
+	for _, t := range p.AllowedTypes {
+		allowed = allowed || t == contentType
+	}
+	if !allowed {
+		return "", reject(415, RejectTypeNotAllowed, "file content is %s; allowed: %s", contentType, strings.Join(p.AllowedTypes, ", "))
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	res, err := s.Scanner.Scan(ctx, f)
+	if err != nil {
+		return "", reject(503, RejectScanUnavailable, "virus scan unavailable, try again later")
+	}
+	if !res.Clean {
+		return "", reject(422, RejectMalwareDetected, "file rejected by virus scan: %s", res.Signature)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return contentType, nil
+}
+
+// GET /api/v1/upload-policies
+// Kebijakan efektif untuk setiap tipe prestasi (dan "default").
+func (s *UploadPolicyService) FindAll(c *fiber.Ctx) error {
+	keys := []string{models.DefaultUploadPolicyKey}
+	for _, t := range models.AchievementTypeList() {
+		keys = append(keys, t.Name)
+	}
+	out := make([]models.UploadPolicy, 0, len(keys))
+	for _, key := range keys {
+		p, err := s.PolicyFor(key)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		p.AchievementType = key
+		out = append(out, *p)
+	}
+	return c.JSON(fiber.Map{
+		"policies":        out,
+		"supported_types": models.SupportedUploadTypes,
+		"server_limit":    s.MaxFileSize(),
+		"request_limit":   config.MaxUploadBytes(), // di atas ini pakai upload resumable
+		"scanner":         s.Scanner.Name(),
+	})
+}
+
+// PUT /api/v1/upload-policies/:type (type = nama tipe prestasi atau "default")
+func (s *UploadPolicyService) Update(c *fiber.Ctx) error {
+	key := c.Params("type")
+	var req models.UpdateUploadPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+	if err := models.ValidateUploadPolicy(key, req, s.MaxFileSize()); err != nil {
+		return respondValidationError(c, err)
+	}
+
+	actor, _ := c.Locals("user_id").(string)
+	p, err := s.Repo.Upsert(&models.UploadPolicy{
+		AchievementType: key,
+		MaxSizeBytes:    req.MaxSizeBytes,
+		AllowedTypes:    req.AllowedTypes,
+	}, actor)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.AuditRepo.Log(actor, "upload_policy.update", "upload_policy", key, map[string]interface{}{
+		"max_size_bytes": p.MaxSizeBytes,
+		"allowed_types":  p.AllowedTypes,
+	}, c.IP()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(p)
+}
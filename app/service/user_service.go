@@ -1,27 +1,41 @@
 package service
 
 import (
+	"errors"
+
 	"github.com/Lutfania/ekrp/app/models"
 	"github.com/Lutfania/ekrp/app/repository"
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
-	Repo *repository.UserRepository
+	Repo           *repository.UserRepository
+	RevocationRepo *repository.TokenRevocationRepository
+	RefreshRepo    *repository.RefreshTokenRepository
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
-	return &UserService{Repo: repo}
+func NewUserService(repo *repository.UserRepository, revocationRepo *repository.TokenRevocationRepository, refreshRepo *repository.RefreshTokenRepository) *UserService {
+	return &UserService{Repo: repo, RevocationRepo: revocationRepo, RefreshRepo: refreshRepo}
 }
 
-// GET /users
+// GET /users?role_id=&is_active=&q=&from=&to=&limit=&cursor=&sort=&total=
 func (s *UserService) FindAll(c *fiber.Ctx) error {
-	users, err := s.Repo.FindAll()
+	filter := models.UserFilter{RoleID: c.Query("role_id"), Search: c.Query("q")}
+	if v := c.Query("is_active"); v != "" {
+		active := c.QueryBool("is_active")
+		filter.IsActive = &active
+	}
+	var err error
+	if filter.From, filter.To, err = dateRange(c, "from", "to"); err != nil {
+		return respondPageError(c, err)
+	}
+	page, err := s.Repo.FindPage(filter, pageRequest(c))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return respondPageError(c, err)
 	}
-	return c.JSON(users)
+	return c.JSON(page)
 }
 
 // GET /users/:id
@@ -86,6 +100,10 @@ func (s *UserService) DeleteUser(c *fiber.Ctx) error {
 	if err := s.Repo.DeleteUser(id); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+	// token yang masih beredar tidak boleh dipakai lagi
+	if err := s.RevocationRepo.RevokeUser(id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	return c.JSON(fiber.Map{"message": "User deleted"})
 }
@@ -104,9 +122,49 @@ func (s *UserService) UpdateUserRole(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid role request"})
 	}
 
-	if err := s.Repo.UpdateUserRole(id, req.RoleID); err != nil {
+	// permissions di token lama sudah tidak sesuai role baru => paksa login
+	// ulang; pencabutan dan audit ikut transaksi perubahan role
+	actor, _ := c.Locals("user_id").(string)
+	audit := models.AuditEntry{ActorID: actor, Action: "user.role.update", EntityType: "user", EntityID: id, IP: c.IP()}
+	if err := s.Repo.UpdateUserRole(id, req.RoleID, audit); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{"message": "Role updated"})
 }
+
+// PUT /users/:id/status
+func (s *UserService) UpdateUserStatus(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.UpdateUserStatusRequest
+	if err := c.BodyParser(&req); err != nil || req.IsActive == nil {
+		return c.Status(400).JSON(fiber.Map{"error": "is_active required"})
+	}
+
+	if err := s.Repo.UpdateUserStatus(id, *req.IsActive); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if !*req.IsActive {
+		if err := revokeUserSessions(s.RevocationRepo, s.RefreshRepo, id); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(fiber.Map{"message": "Status updated"})
+}
+
+// POST /users/:id/logout-all
+func (s *UserService) LogoutAll(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if err := revokeUserSessions(s.RevocationRepo, s.RefreshRepo, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "User logged out from all devices"})
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"html/template"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lutfania/ekrp/app/models"
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/Lutfania/ekrp/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/jackc/pgx/v5"
+)
+
+// VerificationService mengelola kode verifikasi publik (QR) prestasi yang
+// sudah diverifikasi. Kode pertama diterbitkan oleh AchievementService.Verify.
+type VerificationService struct {
+	Repo      *repository.VerificationRepository
+	MongoRepo *repository.MongoAchievementRepository
+	Policy    *AchievementPolicy
+	AuditRepo *repository.AuditRepository
+}
+
+func NewVerificationService(repo *repository.VerificationRepository, mongo *repository.MongoAchievementRepository, policy *AchievementPolicy, audit *repository.AuditRepository) *VerificationService {
+	return &VerificationService{Repo: repo, MongoRepo: mongo, Policy: policy, AuditRepo: audit}
+}
+
+// isVerifiedStatus: kode hanya berlaku untuk prestasi verified (atau yang
+// sudah diarsipkan setelah verified).
+func isVerifiedStatus(status string) bool {
+	return status == models.StatusVerified || status == models.StatusArchived
+}
+
+// GET /api/v1/achievements/:id/verification
+// Semua kode prestasi (aktif dan yang sudah dicabut).
+func (s *VerificationService) List(c *fiber.Ctx) error {
+	ar, _, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	codes, err := s.Repo.ListByAchievement(ar.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	for i := range codes {
+		codes[i].URL = utils.VerificationURL(codes[i].Code)
+	}
+	return c.JSON(fiber.Map{"data": codes})
+}
+
+// GET /api/v1/achievements/:id/verification/qr?size=256
+// PNG QR code yang menunjuk ke /verify/:code dari kode aktif.
+func (s *VerificationService) QRCode(c *fiber.Ctx) error {
+	ar, _, err := s.Policy.Authorize(c, c.Params("id"), ActionView)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	v, err := s.Repo.FindActive(ar.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(404).JSON(fiber.Map{"error": "no active verification code"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	size := c.QueryInt("size", 256)
+	if size < 64 || size > 1024 {
+		return c.Status(400).JSON(fiber.Map{"error": "size must be between 64 and 1024"})
+	}
+	png, err := utils.VerificationQR(v.Code, size)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set(fiber.HeaderContentType, "image/png")
+	return c.Send(png)
+}
+
+// POST /api/v1/achievements/:id/verification
+// Terbitkan kode baru; kode aktif sebelumnya otomatis dicabut.
+func (s *VerificationService) Reissue(c *fiber.Ctx) error {
+	ar, actor, err := s.Policy.Authorize(c, c.Params("id"), ActionVerify)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	if !isVerifiedStatus(ar.Status) {
+		return c.Status(409).JSON(fiber.Map{"error": "achievement is not verified", "current_status": ar.Status})
+	}
+	code, err := utils.GenerateVerificationCode()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	v, err := s.Repo.Reissue(ar.ID, code, actor.UserID, "reissued")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	v.URL = utils.VerificationURL(v.Code)
+	if err := s.AuditRepo.Log(actor.UserID, "verification_code.issue", "achievement", ar.ID, nil, c.IP()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "audit failed: " + err.Error()})
+	}
+	return c.Status(201).JSON(v)
+}
+
+// POST /api/v1/achievements/:id/verification/revoke
+// Body: {"reason": "..."}; setelah ini /verify/:code menampilkan "revoked".
+func (s *VerificationService) Revoke(c *fiber.Ctx) error {
+	var req models.RevokeVerificationRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+		}
+	}
+	ar, actor, err := s.Policy.Authorize(c, c.Params("id"), ActionVerify)
+	if err != nil {
+		return respondPolicyError(c, err)
+	}
+	revoked, err := s.Repo.Revoke(ar.ID, actor.UserID, strings.TrimSpace(req.Reason))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !revoked {
+		return c.Status(404).JSON(fiber.Map{"error": "no active verification code"})
+	}
+	if err := s.AuditRepo.Log(actor.UserID, "verification_code.revoke", "achievement", ar.ID, map[string]interface{}{
+		"reason": req.Reason,
+	}, c.IP()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "audit failed: " + err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "verification code revoked"})
+}
+
+var verificationPage = template.Must(template.New("verify").Parse(`<!DOCTYPE html>
+<html lang="id">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Verifikasi Prestasi</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 2em auto;">
+{{if eq .Status "valid"}}<h1>✅ Prestasi terverifikasi</h1>{{else}}<h1>❌ Kode verifikasi telah dicabut</h1>{{end}}
+<table>
+<tr><th align="left">Mahasiswa</th><td>{{.StudentName}}</td></tr>
+<tr><th align="left">Prestasi</th><td>{{.AchievementTitle}}</td></tr>
+<tr><th align="left">Diverifikasi oleh</th><td>{{.VerifiedBy}}</td></tr>
+<tr><th align="left">Tanggal verifikasi</th><td>{{if .VerifiedAt}}{{.VerifiedAt.Format "02 January 2006"}}{{end}}</td></tr>
+{{if .RevokedAt}}<tr><th align="left">Dicabut</th><td>{{.RevokedAt.Format "02 January 2006"}}</td></tr>{{end}}
+</table>
+<p><small>Kode: {{.Code}}</small></p>
+</body>
+</html>
+`))
+
+// GET /verify/:code (publik, tanpa login, dibatasi rate limiter)
+// JSON secara default; browser (Accept: text/html) mendapat halaman HTML.
+func (s *VerificationService) Public(c *fiber.Ctx) error {
+	code := strings.ToLower(c.Params("code"))
+	v, err := s.Repo.FindPublic(code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(404).JSON(fiber.Map{"error": "unknown verification code"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "verification unavailable"})
+	}
+	v.Status = models.VerificationValid
+	if v.RevokedAt != nil || !isVerifiedStatus(v.AchievementStatus) {
+		v.Status = models.VerificationRevoked
+	}
+	if doc, err := s.MongoRepo.FindByIDHex(v.MongoAchievementID); err == nil && doc != nil {
+		v.AchievementTitle = doc.Title
+	}
+
+	status := 200
+	if v.Status != models.VerificationValid {
+		status = 410
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if c.Accepts(fiber.MIMEApplicationJSON, fiber.MIMETextHTML) == fiber.MIMETextHTML {
+		var b strings.Builder
+		if err := verificationPage.Execute(&b, v); err != nil {
+			return c.Status(500).SendString("verification unavailable")
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Status(status).SendString(b.String())
+	}
+	return c.Status(status).JSON(v)
+}
+
+// VerificationLimiter limits /verify/:code per IP to VERIFY_RATE_LIMIT
+// requests per minute (default 30) supaya kode tidak bisa ditebak massal.
+func VerificationLimiter() fiber.Handler {
+	max := 30
+	if n, err := strconv.Atoi(os.Getenv("VERIFY_RATE_LIMIT")); err == nil && n > 0 {
+		max = n
+	}
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: time.Minute,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(429).JSON(fiber.Map{"error": "too many verification requests, try again later"})
+		},
+	})
+}
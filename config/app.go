@@ -1,14 +1,85 @@
 package config
 
 import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
+// MaxBodyBytes reads MAX_BODY_KB (default 1 MB): batas body request untuk
+// semua route selain upload lampiran.
+func MaxBodyBytes() int64 {
+	if kb, err := strconv.Atoi(os.Getenv("MAX_BODY_KB")); err == nil && kb > 0 {
+		return int64(kb) << 10
+	}
+	return 1 << 20
+}
+
+// MaxUploadBytes reads MAX_UPLOAD_MB (default 50 MB): batas body request
+// pada route upload, jadi batas atas untuk semua kebijakan ukuran lampiran.
+func MaxUploadBytes() int64 {
+	if mb, err := strconv.Atoi(os.Getenv("MAX_UPLOAD_MB")); err == nil && mb > 0 {
+		return int64(mb) << 20
+	}
+	return 50 << 20
+}
+
+// MaxResumableUploadBytes reads TUS_MAX_SIZE_MB (default 1024 MB): ukuran
+// maksimal satu file lewat upload resumable (tus), dikirim per potongan yang
+// masing-masing tetap dibatasi MaxUploadBytes.
+func MaxResumableUploadBytes() int64 {
+	if mb, err := strconv.Atoi(os.Getenv("TUS_MAX_SIZE_MB")); err == nil && mb > 0 {
+		return int64(mb) << 20
+	}
+	return 1024 << 20
+}
+
+// proxyConfig reads PROXY_HEADER dan TRUSTED_PROXIES (IP/CIDR dipisah
+// koma). c.IP() — kunci rate limit verifikasi, IP di riwayat dan audit —
+// hanya mengambil header dari koneksi yang datang lewat proxy terdaftar;
+// request lain tetap memakai alamat koneksinya.
+func proxyConfig(cfg *fiber.Config) {
+	header := strings.TrimSpace(os.Getenv("PROXY_HEADER"))
+	if header == "" {
+		return
+	}
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	if len(proxies) == 0 {
+		log.Println("config: PROXY_HEADER diabaikan karena TRUSTED_PROXIES kosong")
+	}
+	cfg.ProxyHeader = header
+	cfg.EnableTrustedProxyCheck = true
+	cfg.TrustedProxies = proxies
+	// header berisi daftar (X-Forwarded-For): ambil IP valid pertama
+	cfg.EnableIPValidation = true
+}
+
 func NewApp() *fiber.App {
-	app := fiber.New()
+	// body di atas BodyLimit tidak ditolak fasthttp melainkan di-stream;
+	// batas per route dicek oleh middleware.BodyLimit. Multipart tidak
+	// di-parse sebelum handler supaya route non-upload tidak menulis file
+	// sementara.
+	cfg := fiber.Config{
+		BodyLimit:                    int(MaxBodyBytes()),
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	}
+	proxyConfig(&cfg)
+	app := fiber.New(cfg)
 
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		// header tus harus terbaca oleh klien browser
+		ExposeHeaders: "Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Upload-Offset, Upload-Length, Upload-Expires, Upload-File-Id",
+	}))
 
 	return app
 }
@@ -6,10 +6,12 @@ import (
 	"log"
 	"os"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var DB *pgx.Conn
+// DB is a connection pool so concurrent requests (and transactions) don't
+// fight over a single connection.
+var DB *pgxpool.Pool
 
 func InitPostgres() error {
 	url := os.Getenv("DATABASE_URL")
@@ -17,12 +19,15 @@ func InitPostgres() error {
 		return fmt.Errorf("DATABASE_URL is missing in .env")
 	}
 
-	conn, err := pgx.Connect(context.Background(), url)
+	pool, err := pgxpool.New(context.Background(), url)
 	if err != nil {
 		return err
 	}
+	if err := pool.Ping(context.Background()); err != nil {
+		return err
+	}
 
-	DB = conn
+	DB = pool
 	log.Println("✅ PostgreSQL connected")
 	return nil
 }
@@ -0,0 +1,110 @@
+package credential
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"time"
+)
+
+// Cryptosuite is the Data Integrity cryptosuite used for Open Badges proofs.
+const Cryptosuite = "eddsa-jcs-2022"
+
+// Proof is a W3C Data Integrity proof (DataIntegrityProof, eddsa-jcs-2022).
+type Proof struct {
+	Type               string `json:"type"`
+	Cryptosuite        string `json:"cryptosuite"`
+	Created            string `json:"created"`
+	VerificationMethod string `json:"verificationMethod"`
+	ProofPurpose       string `json:"proofPurpose"`
+	ProofValue         string `json:"proofValue,omitempty"`
+}
+
+// proofHash is SHA-256(JCS(proof config + @context)) || SHA-256(JCS(document)).
+func proofHash(doc map[string]interface{}, p Proof) ([]byte, error) {
+	config := map[string]interface{}{
+		"type":               p.Type,
+		"cryptosuite":        p.Cryptosuite,
+		"created":            p.Created,
+		"verificationMethod": p.VerificationMethod,
+		"proofPurpose":       p.ProofPurpose,
+	}
+	if ctx, ok := doc["@context"]; ok {
+		config["@context"] = ctx
+	}
+	canonicalConfig, err := Canonicalize(config)
+	if err != nil {
+		return nil, err
+	}
+	unsecured := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		if k != "proof" {
+			unsecured[k] = v
+		}
+	}
+	canonicalDoc, err := Canonicalize(unsecured)
+	if err != nil {
+		return nil, err
+	}
+	h1, h2 := sha256.Sum256(canonicalConfig), sha256.Sum256(canonicalDoc)
+	return append(h1[:], h2[:]...), nil
+}
+
+// AddProof signs doc with key and sets doc["proof"]. verificationMethod harus
+// bisa di-resolve ke kunci publik key (mis. profil issuer + "#" + kid).
+func AddProof(doc map[string]interface{}, key *Key, verificationMethod string, created time.Time) error {
+	if key.Private == nil {
+		return errors.New("key has no private part")
+	}
+	p := Proof{
+		Type:               "DataIntegrityProof",
+		Cryptosuite:        Cryptosuite,
+		Created:            created.UTC().Format(time.RFC3339),
+		VerificationMethod: verificationMethod,
+		ProofPurpose:       "assertionMethod",
+	}
+	hash, err := proofHash(doc, p)
+	if err != nil {
+		return err
+	}
+	p.ProofValue = EncodeMultibase(ed25519.Sign(key.Private, hash))
+	doc["proof"] = p
+	return nil
+}
+
+// VerifyProof checks the embedded proof of doc (a decoded JSON object) using
+// the key that lookup returns for the proof's verificationMethod.
+func VerifyProof(doc map[string]interface{}, lookup func(verificationMethod string) (ed25519.PublicKey, bool)) (*Proof, error) {
+	raw, ok := doc["proof"].(map[string]interface{})
+	if !ok {
+		return nil, ErrMalformed
+	}
+	str := func(k string) string { s, _ := raw[k].(string); return s }
+	p := &Proof{
+		Type:               str("type"),
+		Cryptosuite:        str("cryptosuite"),
+		Created:            str("created"),
+		VerificationMethod: str("verificationMethod"),
+		ProofPurpose:       str("proofPurpose"),
+		ProofValue:         str("proofValue"),
+	}
+	if p.Type != "DataIntegrityProof" || p.Cryptosuite != Cryptosuite || p.ProofPurpose != "assertionMethod" {
+		return p, ErrMalformed
+	}
+	sig, err := DecodeMultibase(p.ProofValue)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return p, ErrMalformed
+	}
+	pub, ok := lookup(p.VerificationMethod)
+	if !ok {
+		return p, ErrUnknownKey
+	}
+	hash, err := proofHash(doc, *p)
+	if err != nil {
+		return p, ErrMalformed
+	}
+	if !ed25519.Verify(pub, hash, sig) {
+		return p, ErrBadSignature
+	}
+	return p, nil
+}
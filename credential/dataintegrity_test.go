@@ -0,0 +1,120 @@
+package credential
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testVerificationMethod = "https://ekrp.example/api/v1/openbadges/issuer#key-1"
+
+func testBadge() map[string]interface{} {
+	return map[string]interface{}{
+		"@context": []interface{}{"https://www.w3.org/ns/credentials/v2", "https://purl.imsglobal.org/spec/ob/v3p0/context-3.0.3.json"},
+		"type":     []interface{}{"VerifiableCredential", "OpenBadgeCredential"},
+		"issuer":   map[string]interface{}{"id": "https://ekrp.example/api/v1/openbadges/issuer", "type": []interface{}{"Profile"}},
+		"credentialSubject": map[string]interface{}{
+			"type":        []interface{}{"AchievementSubject"},
+			"achievement": map[string]interface{}{"name": "Juara 1 Gemastik", "score": 95.5},
+		},
+	}
+}
+
+// signedBadge returns a signed credential as a verifier receives it (JSON
+// yang sudah di-decode ulang).
+func signedBadge(t *testing.T, k *Key) map[string]interface{} {
+	t.Helper()
+	doc := testBadge()
+	if err := AddProof(doc, k, testVerificationMethod, time.Date(2026, 10, 18, 1, 2, 3, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestDataIntegrityProof(t *testing.T) {
+	k := rfc8037Key(t)
+	lookup := func(vm string) (ed25519.PublicKey, bool) {
+		if vm != testVerificationMethod {
+			return nil, false
+		}
+		return k.Public, true
+	}
+
+	doc := signedBadge(t, k)
+	p, err := VerifyProof(doc, lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Created != "2026-10-18T01:02:03Z" || p.Cryptosuite != Cryptosuite || p.VerificationMethod != testVerificationMethod {
+		t.Fatalf("unexpected proof %+v", p)
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func(doc map[string]interface{})
+		lookup  func(string) (ed25519.PublicKey, bool)
+		wantErr error
+	}{
+		{"document field", func(doc map[string]interface{}) {
+			doc["credentialSubject"].(map[string]interface{})["achievement"].(map[string]interface{})["name"] = "Juara 2 Gemastik"
+		}, lookup, ErrBadSignature},
+		{"number", func(doc map[string]interface{}) {
+			doc["credentialSubject"].(map[string]interface{})["achievement"].(map[string]interface{})["score"] = 95.6
+		}, lookup, ErrBadSignature},
+		{"added field", func(doc map[string]interface{}) {
+			doc["validUntil"] = "2030-01-01T00:00:00Z"
+		}, lookup, ErrBadSignature},
+		{"proof created", func(doc map[string]interface{}) {
+			doc["proof"].(map[string]interface{})["created"] = "2026-10-19T01:02:03Z"
+		}, lookup, ErrBadSignature},
+		{"context", func(doc map[string]interface{}) {
+			doc["@context"] = []interface{}{"https://www.w3.org/ns/credentials/v2"}
+		}, lookup, ErrBadSignature},
+		{"cryptosuite", func(doc map[string]interface{}) {
+			doc["proof"].(map[string]interface{})["cryptosuite"] = "eddsa-rdfc-2022"
+		}, lookup, ErrMalformed},
+		{"proof value", func(doc map[string]interface{}) {
+			doc["proof"].(map[string]interface{})["proofValue"] = "z2NEpo7TZRRrLZSi2U"
+		}, lookup, ErrMalformed},
+		{"no proof", func(doc map[string]interface{}) {
+			delete(doc, "proof")
+		}, lookup, ErrMalformed},
+		{"unknown key", func(doc map[string]interface{}) {}, func(string) (ed25519.PublicKey, bool) { return nil, false }, ErrUnknownKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := signedBadge(t, k)
+			tt.tamper(doc)
+			if _, err := VerifyProof(doc, tt.lookup); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// kunci lain dengan verificationMethod yang sama
+	other, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyProof(signedBadge(t, k), func(string) (ed25519.PublicKey, bool) { return other.Public, true }); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("wrong key: err = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestAddProofNeedsPrivateKey(t *testing.T) {
+	k := rfc8037Key(t)
+	k.Private = nil
+	if err := AddProof(testBadge(), k, testVerificationMethod, time.Now()); err == nil {
+		t.Fatal("AddProof without a private key should fail")
+	}
+}
@@ -0,0 +1,81 @@
+package credential
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrMalformed    = errors.New("malformed credential")
+	ErrUnknownKey   = errors.New("unknown signing key")
+	ErrBadSignature = errors.New("invalid signature")
+)
+
+// MediaType is the "typ" of the protected header.
+const MediaType = "ekrp-credential+json"
+
+// Signed is a credential in JWS JSON flattened serialization (RFC 7515
+// §7.2.2): payload is the base64url JSON credential, signed with EdDSA.
+// Library JOSE apa pun bisa memverifikasinya dengan JWKS kita.
+type Signed struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Sign serializes payload to JSON and signs it with key.
+func Sign(payload interface{}, key *Key) (*Signed, error) {
+	if key.Private == nil {
+		return nil, errors.New("key has no private part")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	h, _ := json.Marshal(header{Alg: "EdDSA", Kid: key.ID, Typ: MediaType})
+	s := &Signed{
+		Protected: base64.RawURLEncoding.EncodeToString(h),
+		Payload:   base64.RawURLEncoding.EncodeToString(body),
+	}
+	sig := ed25519.Sign(key.Private, []byte(s.Protected+"."+s.Payload))
+	s.Signature = base64.RawURLEncoding.EncodeToString(sig)
+	return s, nil
+}
+
+// Verify checks s against the public key returned by lookup for its kid and
+// returns the JSON payload and the kid. lookup bisa JWKS.Lookup dari
+// /.well-known/jwks.json yang disimpan sendiri oleh pihak ketiga.
+func Verify(s *Signed, lookup func(kid string) (ed25519.PublicKey, bool)) ([]byte, string, error) {
+	rawHeader, err := base64.RawURLEncoding.DecodeString(s.Protected)
+	if err != nil {
+		return nil, "", ErrMalformed
+	}
+	var h header
+	if err := json.Unmarshal(rawHeader, &h); err != nil || h.Alg != "EdDSA" || h.Kid == "" {
+		return nil, "", ErrMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(s.Signature)
+	if err != nil {
+		return nil, h.Kid, ErrMalformed
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(s.Payload)
+	if err != nil {
+		return nil, h.Kid, ErrMalformed
+	}
+	pub, ok := lookup(h.Kid)
+	if !ok {
+		return nil, h.Kid, ErrUnknownKey
+	}
+	if !ed25519.Verify(pub, []byte(s.Protected+"."+s.Payload), sig) {
+		return nil, h.Kid, ErrBadSignature
+	}
+	return payload, h.Kid, nil
+}
@@ -0,0 +1,114 @@
+package credential
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// publishedJWKS returns the JWKS as a third party would read it from
+// /.well-known/jwks.json.
+func publishedJWKS(t *testing.T, keys ...*Key) JWKS {
+	t.Helper()
+	set := JWKS{}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, k.JWK())
+	}
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var published JWKS
+	if err := json.Unmarshal(raw, &published); err != nil {
+		t.Fatal(err)
+	}
+	return published
+}
+
+// compact is the JWS compact serialization (RFC 7515 §7.1) of s.
+func compact(s *Signed) string {
+	return s.Protected + "." + s.Payload + "." + s.Signature
+}
+
+func parseCompact(t *testing.T, token string) *Signed {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("not a compact JWS: %q", token)
+	}
+	return &Signed{Protected: parts[0], Payload: parts[1], Signature: parts[2]}
+}
+
+func TestRFC8037Signature(t *testing.T) {
+	// RFC 8037 Appendix A.4: JWS {"alg":"EdDSA"} atas "Example of Ed25519 signing"
+	const token = "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc." +
+		"hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg"
+	k := rfc8037Key(t)
+	pub, ok := publishedJWKS(t, k).Lookup(k.ID)
+	if !ok {
+		t.Fatal("key not found in JWKS")
+	}
+	s := parseCompact(t, token)
+	sig, err := base64.RawURLEncoding.DecodeString(s.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pub, []byte(s.Protected+"."+s.Payload), sig) {
+		t.Fatal("RFC 8037 signature does not verify with the published key")
+	}
+	// Ed25519 deterministik: tanda tangan kita sama persis dengan RFC
+	if got := base64.RawURLEncoding.EncodeToString(ed25519.Sign(k.Private, []byte(s.Protected+"."+s.Payload))); got != s.Signature {
+		t.Fatalf("signature = %s, want %s", got, s.Signature)
+	}
+}
+
+func TestVerifyCompactAgainstJWKS(t *testing.T) {
+	active, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := publishedJWKS(t, active, other)
+
+	signed, err := Sign(map[string]string{"achievement": "Juara 1 Gemastik"}, active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := compact(signed)
+
+	payload, kid, err := Verify(parseCompact(t, token), jwks.Lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid != active.ID {
+		t.Fatalf("kid = %s, want %s", kid, active.ID)
+	}
+	if string(payload) != `{"achievement":"Juara 1 Gemastik"}` {
+		t.Fatalf("payload = %s", payload)
+	}
+
+	tampered := *signed
+	tampered.Payload = base64.RawURLEncoding.EncodeToString([]byte(`{"achievement":"Juara 1 Gemastik 2"}`))
+	if _, _, err := Verify(parseCompact(t, compact(&tampered)), jwks.Lookup); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("tampered payload: err = %v, want ErrBadSignature", err)
+	}
+
+	if _, _, err := Verify(parseCompact(t, token), publishedJWKS(t, other).Lookup); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("key not published: err = %v, want ErrUnknownKey", err)
+	}
+
+	// header tanpa kid atau dengan alg lain ditolak
+	for _, h := range []string{`{"alg":"EdDSA"}`, `{"alg":"none","kid":"` + active.ID + `"}`} {
+		bad := *signed
+		bad.Protected = base64.RawURLEncoding.EncodeToString([]byte(h))
+		if _, _, err := Verify(&bad, jwks.Lookup); !errors.Is(err, ErrMalformed) {
+			t.Fatalf("header %s: err = %v, want ErrMalformed", h, err)
+		}
+	}
+}
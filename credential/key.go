@@ -0,0 +1,123 @@
+// Package credential menandatangani dan memverifikasi kredensial prestasi
+// dengan Ed25519. Verifikasi hanya butuh kunci publik (JWKS di
+// /.well-known/jwks.json), jadi pihak ketiga bisa memeriksa kredensial tanpa
+// memanggil database kita.
+package credential
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Key is one institutional signing key. Private is nil for keys loaded only
+// for verification.
+type Key struct {
+	ID        string
+	Public    ed25519.PublicKey
+	Private   ed25519.PrivateKey
+	CreatedAt time.Time
+	RetiredAt *time.Time // kunci lama tetap dipublikasikan untuk verifikasi
+}
+
+// GenerateKey creates a new key whose ID is its JWK thumbprint.
+func GenerateKey() (*Key, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: KeyID(pub), Public: pub, Private: priv, CreatedAt: time.Now()}, nil
+}
+
+// KeyID is the RFC 7638 JWK thumbprint of pub (base64url SHA-256).
+func KeyID(pub ed25519.PublicKey) string {
+	canonical := fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, base64.RawURLEncoding.EncodeToString(pub))
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWK is the public part of a key as published in the JWKS (RFC 8037).
+type JWK struct {
+	Kty       string     `json:"kty"`
+	Crv       string     `json:"crv"`
+	X         string     `json:"x"`
+	Kid       string     `json:"kid"`
+	Use       string     `json:"use"`
+	Alg       string     `json:"alg"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+func (k *Key) JWK() JWK {
+	return JWK{
+		Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(k.Public),
+		Kid: k.ID, Use: "sig", Alg: "EdDSA", CreatedAt: k.CreatedAt, RetiredAt: k.RetiredAt,
+	}
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Lookup returns the public key kid from the set.
+func (s JWKS) Lookup(kid string) (ed25519.PublicKey, bool) {
+	for _, k := range s.Keys {
+		if k.Kid != kid || k.Kty != "OKP" || k.Crv != "Ed25519" {
+			continue
+		}
+		pub, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(pub) != ed25519.PublicKeySize {
+			return nil, false
+		}
+		return pub, true
+	}
+	return nil, false
+}
+
+var errSealed = errors.New("cannot open sealed private key")
+
+func sealer(secret []byte) (cipher.AEAD, error) {
+	sum := sha256.Sum256(secret)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealPrivateKey encrypts the private key (AES-256-GCM, kunci dari secret)
+// supaya tidak tersimpan polos di database.
+func SealPrivateKey(priv ed25519.PrivateKey, secret []byte) ([]byte, error) {
+	aead, err := sealer(secret)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, priv.Seed(), nil), nil
+}
+
+// OpenPrivateKey reverses SealPrivateKey.
+func OpenPrivateKey(sealed, secret []byte) (ed25519.PrivateKey, error) {
+	aead, err := sealer(secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errSealed
+	}
+	seed, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errSealed
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
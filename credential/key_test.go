@@ -0,0 +1,65 @@
+package credential
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+// Kunci uji Ed25519 dari RFC 8037 Appendix A.1.
+const (
+	rfc8037D = "nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"
+	rfc8037X = "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+)
+
+func rfc8037Key(t *testing.T) *Key {
+	t.Helper()
+	seed, err := base64.RawURLEncoding.DecodeString(rfc8037D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	pub := priv.Public().(ed25519.PublicKey)
+	return &Key{ID: KeyID(pub), Public: pub, Private: priv}
+}
+
+func TestKeyIDThumbprint(t *testing.T) {
+	k := rfc8037Key(t)
+	if got := base64.RawURLEncoding.EncodeToString(k.Public); got != rfc8037X {
+		t.Fatalf("public key = %s, want %s", got, rfc8037X)
+	}
+	// RFC 8037 Appendix A.3
+	if want := "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"; k.ID != want {
+		t.Fatalf("KeyID = %s, want %s", k.ID, want)
+	}
+}
+
+func TestSealPrivateKey(t *testing.T) {
+	k, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := SealPrivateKey(k.Private, []byte("rahasia-kunci"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := OpenPrivateKey(sealed, []byte("rahasia-kunci"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opened.Equal(k.Private) {
+		t.Fatal("opened key differs from the sealed one")
+	}
+
+	if _, err := OpenPrivateKey(sealed, []byte("rahasia-lain")); !errors.Is(err, errSealed) {
+		t.Fatalf("wrong secret: err = %v, want errSealed", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := OpenPrivateKey(sealed, []byte("rahasia-kunci")); !errors.Is(err, errSealed) {
+		t.Fatalf("tampered: err = %v, want errSealed", err)
+	}
+	if _, err := OpenPrivateKey([]byte{1, 2, 3}, []byte("rahasia-kunci")); !errors.Is(err, errSealed) {
+		t.Fatalf("short: err = %v, want errSealed", err)
+	}
+}
@@ -0,0 +1,80 @@
+package credential
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// multicodec prefix ed25519-pub (0xed, varint)
+var ed25519MulticodecPrefix = []byte{0xed, 0x01}
+
+var errMultibase = errors.New("invalid multibase value")
+
+// EncodeMultibase encodes b as base58btc multibase ("z..."), format
+// proofValue dan publicKeyMultibase di Data Integrity.
+func EncodeMultibase(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return "z" + string(out)
+}
+
+// DecodeMultibase reverses EncodeMultibase.
+func DecodeMultibase(s string) ([]byte, error) {
+	if len(s) < 2 || s[0] != 'z' {
+		return nil, errMultibase
+	}
+	s = s[1:]
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		idx := -1
+		for j := 0; j < len(base58Alphabet); j++ {
+			if base58Alphabet[j] == s[i] {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, errMultibase
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// PublicKeyMultibase is the Multikey encoding of pub.
+func PublicKeyMultibase(pub ed25519.PublicKey) string {
+	return EncodeMultibase(append(append([]byte{}, ed25519MulticodecPrefix...), pub...))
+}
+
+// ParsePublicKeyMultibase reverses PublicKeyMultibase.
+func ParsePublicKeyMultibase(s string) (ed25519.PublicKey, error) {
+	b, err := DecodeMultibase(s)
+	if err != nil || len(b) != 2+ed25519.PublicKeySize || b[0] != 0xed || b[1] != 0x01 {
+		return nil, errMultibase
+	}
+	return ed25519.PublicKey(b[2:]), nil
+}
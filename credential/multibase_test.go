@@ -0,0 +1,71 @@
+package credential
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestMultibaseBase58btc(t *testing.T) {
+	// vektor dari draft-msporny-base58 (ditambah prefix multibase "z")
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"hello world", []byte("Hello World!"), "z2NEpo7TZRRrLZSi2U"},
+		{"quick brown fox", []byte("The quick brown fox jumps over the lazy dog."), "zUSm3fpXnKG5EUBx2ndxBDMPVciP5hGey2Jh4NDv6gmeo1LkMeiKrLJUUBk6Z"},
+		{"leading zeros", mustHex(t, "0000287fb4cd"), "z11233QC4"},
+		{"single zero", []byte{0}, "z1"},
+		{"zeros then one", []byte{0, 0, 0, 1}, "z1112"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodeMultibase(tt.data); got != tt.want {
+				t.Fatalf("EncodeMultibase = %s, want %s", got, tt.want)
+			}
+			back, err := DecodeMultibase(tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(back, tt.data) {
+				t.Fatalf("DecodeMultibase = %x, want %x", back, tt.data)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "z", "m2NEpo7TZRRrLZSi2U", "z0OIl"} {
+		if _, err := DecodeMultibase(bad); err == nil {
+			t.Errorf("DecodeMultibase(%q) should fail", bad)
+		}
+	}
+}
+
+func TestPublicKeyMultibase(t *testing.T) {
+	k := rfc8037Key(t)
+	mb := PublicKeyMultibase(k.Public)
+	// Multikey Ed25519 selalu diawali "z6Mk"
+	if !strings.HasPrefix(mb, "z6Mk") {
+		t.Fatalf("PublicKeyMultibase = %s, want prefix z6Mk", mb)
+	}
+	pub, err := ParsePublicKeyMultibase(mb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(k.Public) {
+		t.Fatal("round trip changed the public key")
+	}
+	if _, err := ParsePublicKeyMultibase(EncodeMultibase(k.Public)); err == nil {
+		t.Fatal("key without multicodec prefix should be rejected")
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -41,13 +42,47 @@ func Collection(name string) *mongo.Collection {
 	return MongoClient.Database(db).Collection(name)
 }
 
-// Optional helper to ensure indexes for attachments/achievements
-func EnsureIndexes() error {
+const achievementTextIndex = "achievement_text"
+
+// EnsureIndexes creates the indexes of the achievements and
+// achievement_versions collections, including the text index used by search
+// over title, description and the given extra keys. Dipanggil sekali saat startup.
+func EnsureIndexes(textExtraFields []string) error {
 	coll := Collection("achievements")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+	// satu snapshot per versi; snapshot ditulis dengan upsert
+	if _, err := Collection("achievement_versions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "achievement_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	if _, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{{Key: "student_id", Value: 1}},
-	})
+	}); err != nil {
+		return err
+	}
+
+	keys := bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}}
+	weights := bson.D{{Key: "title", Value: 10}, {Key: "description", Value: 3}}
+	for _, f := range textExtraFields {
+		keys = append(keys, bson.E{Key: "extra." + f, Value: "text"})
+		weights = append(weights, bson.E{Key: "extra." + f, Value: 5})
+	}
+	text := mongo.IndexModel{
+		Keys: keys,
+		// "none": tanpa stemming/stop word, isi campuran Indonesia & Inggris
+		Options: options.Index().SetName(achievementTextIndex).SetWeights(weights).SetDefaultLanguage("none"),
+	}
+	_, err := coll.Indexes().CreateOne(ctx, text)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == 85 || cmdErr.Code == 86) {
+		// definisi index berubah (mis. field extra baru): buat ulang
+		if _, err := coll.Indexes().DropOne(ctx, achievementTextIndex); err != nil {
+			return err
+		}
+		_, err = coll.Indexes().CreateOne(ctx, text)
+	}
 	return err
 }
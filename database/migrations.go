@@ -0,0 +1,254 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Lutfania/ekrp/config"
+)
+
+// pgMigrations berisi DDL tambahan di luar skema awal (users, roles, students, ...).
+// Semua statement harus idempotent karena dijalankan setiap startup.
+var pgMigrations = []string{
+	// refresh token (opaque, disimpan dalam bentuk hash). family_id mengelompokkan
+	// hasil rotasi dari satu login sehingga reuse bisa mencabut seluruh rantai.
+	`CREATE TABLE IF NOT EXISTS refresh_tokens (
+		id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		family_id   UUID NOT NULL,
+		token_hash  TEXT NOT NULL UNIQUE,
+		expires_at  TIMESTAMPTZ NOT NULL,
+		used_at     TIMESTAMPTZ,
+		revoked_at  TIMESTAMPTZ,
+		replaced_by UUID,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens (user_id)`,
+
+	// access token yang dicabut sebelum expire (per jti), plus versi token per
+	// user untuk "logout everywhere": setiap pencabutan menaikkan token_version
+	// dan token dengan klaim tv < token_version ditolak. Sengaja tanpa FK ke
+	// users supaya pencabutan tetap berlaku setelah user dihapus.
+	`CREATE TABLE IF NOT EXISTS revoked_tokens (
+		jti        TEXT PRIMARY KEY,
+		user_id    UUID,
+		expires_at TIMESTAMPTZ NOT NULL,
+		revoked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE TABLE IF NOT EXISTS user_token_revocations (
+		user_id       UUID PRIMARY KEY,
+		token_version INT NOT NULL
+	)`,
+
+	// audit trail umum (perubahan role/permission, dll)
+	`CREATE TABLE IF NOT EXISTS audit_logs (
+		id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		actor_id    UUID,
+		action      TEXT NOT NULL,
+		entity_type TEXT NOT NULL,
+		entity_id   TEXT NOT NULL,
+		details     JSONB,
+		ip          TEXT,
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity_type, entity_id, created_at DESC)`,
+
+	// riwayat status prestasi; kolom actor/request ditambahkan ke tabel lama bila sudah ada
+	`CREATE TABLE IF NOT EXISTS achievement_reference_history (
+		id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		achievement_ref_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		old_status         TEXT NOT NULL,
+		new_status         TEXT NOT NULL,
+		changed_by         UUID,
+		note               TEXT,
+		changed_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`ALTER TABLE achievement_reference_history ADD COLUMN IF NOT EXISTS actor_name TEXT`,
+	`ALTER TABLE achievement_reference_history ADD COLUMN IF NOT EXISTS actor_role TEXT`,
+	`ALTER TABLE achievement_reference_history ADD COLUMN IF NOT EXISTS ip TEXT`,
+	`ALTER TABLE achievement_reference_history ADD COLUMN IF NOT EXISTS user_agent TEXT`,
+	`ALTER TABLE achievement_reference_history ADD COLUMN IF NOT EXISTS request_id TEXT`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_history_ref ON achievement_reference_history (achievement_ref_id, changed_at DESC)`,
+
+	// tipe prestasi (lihat models.AchievementTypes), juga disimpan di dokumen Mongo
+	`ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS achievement_type TEXT`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_refs_type ON achievement_references (achievement_type, status)`,
+
+	// aturan poin, diversi per tahun akademik (versi terbaru = aktif)
+	`CREATE TABLE IF NOT EXISTS point_rule_sets (
+		id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		academic_year TEXT NOT NULL,
+		version       INT NOT NULL,
+		created_by    UUID,
+		created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+		UNIQUE (academic_year, version)
+	)`,
+	`CREATE TABLE IF NOT EXISTS point_rules (
+		id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		rule_set_id      UUID NOT NULL REFERENCES point_rule_sets(id) ON DELETE CASCADE,
+		achievement_type TEXT NOT NULL,
+		level            TEXT NOT NULL,
+		rank             TEXT NOT NULL DEFAULT '',
+		points           INT NOT NULL,
+		UNIQUE (rule_set_id, achievement_type, level, rank)
+	)`,
+	`ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS points INT`,
+	`ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS point_rule_set_id UUID`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_refs_student_status ON achievement_references (student_id, status)`,
+
+	// mahasiswa bisa memilih untuk tidak tampil di leaderboard publik
+	`ALTER TABLE students ADD COLUMN IF NOT EXISTS public_listing BOOLEAN NOT NULL DEFAULT true`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_refs_verified_at ON achievement_references (verified_at) WHERE status IN ('verified', 'archived')`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_refs_created_at ON achievement_references (created_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_students_advisor ON students (advisor_id)`,
+
+	// index untuk cursor pagination (urutan default + kunci id) dan filter list
+	`CREATE INDEX IF NOT EXISTS idx_achievement_refs_created_id ON achievement_references (created_at DESC, id)`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_refs_status_created ON achievement_references (status, created_at DESC, id)`,
+	`CREATE INDEX IF NOT EXISTS idx_users_username_id ON users (username, id)`,
+	`CREATE INDEX IF NOT EXISTS idx_users_role_active ON users (role_id, is_active)`,
+	`CREATE INDEX IF NOT EXISTS idx_students_student_id_id ON students (student_id, id)`,
+	`CREATE INDEX IF NOT EXISTS idx_students_program_year ON students (program_study, academic_year)`,
+	`CREATE INDEX IF NOT EXISTS idx_lecturers_lecturer_id_id ON lecturers (lecturer_id, id)`,
+	`CREATE INDEX IF NOT EXISTS idx_lecturers_department ON lecturers (department)`,
+
+	// batas ukuran & tipe lampiran per tipe prestasi ('default' = fallback)
+	`CREATE TABLE IF NOT EXISTS upload_policies (
+		achievement_type TEXT PRIMARY KEY,
+		max_size_bytes BIGINT NOT NULL,
+		allowed_types TEXT[] NOT NULL,
+		updated_by UUID,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+
+	// upload resumable (tus); isi sementara ada di TUS_UPLOAD_DIR
+	`CREATE TABLE IF NOT EXISTS resumable_uploads (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		achievement_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		file_name TEXT NOT NULL,
+		upload_length BIGINT NOT NULL,
+		upload_offset BIGINT NOT NULL DEFAULT 0,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_resumable_uploads_expires ON resumable_uploads (expires_at)`,
+
+	// kode verifikasi publik (QR) untuk prestasi terverifikasi
+	`CREATE TABLE IF NOT EXISTS verification_codes (
+		code TEXT PRIMARY KEY,
+		achievement_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		issued_by UUID REFERENCES users(id) ON DELETE SET NULL,
+		issued_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		revoked_at TIMESTAMPTZ,
+		revoked_by UUID REFERENCES users(id) ON DELETE SET NULL,
+		revoke_reason TEXT
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_verification_codes_active ON verification_codes (achievement_id) WHERE revoked_at IS NULL`,
+
+	// kunci Ed25519 penandatangan kredensial; hanya satu yang aktif
+	`CREATE TABLE IF NOT EXISTS credential_keys (
+		kid TEXT PRIMARY KEY,
+		public_key BYTEA NOT NULL,
+		private_key BYTEA NOT NULL,
+		created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		retired_at TIMESTAMPTZ
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_credential_keys_active ON credential_keys ((true)) WHERE retired_at IS NULL`,
+
+	// komentar diskusi prestasi
+	`CREATE TABLE IF NOT EXISTS achievement_comments (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		achievement_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+		user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+		author_name TEXT NOT NULL DEFAULT '',
+		author_role TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_achievement_comments_achievement ON achievement_comments (achievement_id, created_at, id)`,
+
+	// notifikasi in-app; preferensi per user per event (tanpa baris = aktif)
+	`CREATE TABLE IF NOT EXISTS notifications (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		event TEXT NOT NULL,
+		achievement_id UUID REFERENCES achievement_references(id) ON DELETE CASCADE,
+		actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+		actor_name TEXT,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL,
+		read_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications (user_id, created_at DESC, id)`,
+	`CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications (user_id) WHERE read_at IS NULL`,
+	`CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		event TEXT NOT NULL,
+		in_app BOOLEAN NOT NULL DEFAULT true,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (user_id, event)
+	)`,
+
+	// email notifikasi: preferensi per event, bahasa per user, dan outbox
+	// yang ditulis dalam transaksi perubahan status
+	`ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS email BOOLEAN NOT NULL DEFAULT true`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'id'`,
+	`CREATE TABLE IF NOT EXISTS email_outbox (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+		event TEXT NOT NULL,
+		achievement_id UUID REFERENCES achievement_references(id) ON DELETE SET NULL,
+		to_address TEXT NOT NULL,
+		to_name TEXT NOT NULL DEFAULT '',
+		subject TEXT NOT NULL,
+		text_body TEXT NOT NULL,
+		html_body TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INT NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		last_error TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		sent_at TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox (next_attempt_at) WHERE status = 'pending'`,
+	`CREATE INDEX IF NOT EXISTS idx_email_outbox_status_created ON email_outbox (status, created_at DESC, id)`,
+
+	// akses global prestasi ditentukan permission, bukan nama role. Saat
+	// permission pertama kali dibuat, diberikan ke role bernama admin; setelah
+	// itu role bebas diganti nama.
+	`WITH p AS (
+		INSERT INTO permissions (name, description)
+		SELECT 'achievement:manage', 'Akses global ke semua prestasi'
+		WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE name = 'achievement:manage')
+		RETURNING id
+	)
+	INSERT INTO role_permissions (role_id, permission_id)
+	SELECT r.id, p.id FROM roles r, p WHERE lower(r.name) = 'admin'`,
+
+	// berkomentar punya permission sendiri; saat pertama dibuat diberikan ke
+	// semua role yang sudah punya achievement:read (perilaku sebelumnya).
+	`WITH p AS (
+		INSERT INTO permissions (name, description)
+		SELECT 'achievement:comment', 'Menambahkan komentar pada prestasi'
+		WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE name = 'achievement:comment')
+		RETURNING id
+	)
+	INSERT INTO role_permissions (role_id, permission_id)
+	SELECT rp.role_id, p.id
+	FROM role_permissions rp
+	JOIN permissions rd ON rd.id = rp.permission_id AND rd.name = 'achievement:read', p`,
+}
+
+// MigratePostgres applies pgMigrations in order.
+func MigratePostgres() error {
+	for i, stmt := range pgMigrations {
+		if _, err := config.DB.Exec(context.Background(), stmt); err != nil {
+			return fmt.Errorf("migration #%d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package mailer mengirim email notifikasi. Pengiriman tidak dipanggil
+// langsung dari request: email diantrekan di outbox Postgres dan dikirim
+// oleh worker (lihat service.EmailWorker).
+package mailer
+
+import (
+	"context"
+	"net/mail"
+	"os"
+)
+
+// Message is one rendered email.
+type Message struct {
+	To      mail.Address
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers a message; errors are retried by the outbox worker.
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, m Message) error
+}
+
+// FromEnv returns the SMTP sender configured by SMTP_HOST, or nil when email
+// is disabled (SMTP_HOST kosong).
+//
+//	SMTP_HOST, SMTP_PORT (default 25), SMTP_USERNAME, SMTP_PASSWORD,
+//	SMTP_FROM (default "ekrp <no-reply@localhost>"),
+//	SMTP_TLS (starttls = default, implicit = TLS langsung mis. port 465, none)
+//
+// Untuk uji lokal pakai MailHog: SMTP_HOST=localhost SMTP_PORT=1025 SMTP_TLS=none.
+func FromEnv() (Sender, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "25"
+	}
+	fromRaw := os.Getenv("SMTP_FROM")
+	if fromRaw == "" {
+		fromRaw = "ekrp <no-reply@localhost>"
+	}
+	from, err := mail.ParseAddress(fromRaw)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := ParseTLSMode(os.Getenv("SMTP_TLS"))
+	if err != nil {
+		return nil, err
+	}
+	return &SMTP{
+		Addr:     host + ":" + port,
+		Host:     host,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     *from,
+		TLS:      mode,
+	}, nil
+}
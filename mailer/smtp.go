@@ -0,0 +1,167 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// TLSMode is how the SMTP connection is encrypted (SMTP_TLS).
+type TLSMode string
+
+const (
+	TLSStartTLS TLSMode = "starttls" // wajib STARTTLS; gagal bila server tidak menawarkannya
+	TLSImplicit TLSMode = "implicit" // TLS langsung sejak koneksi dibuka, mis. port 465
+	TLSNone     TLSMode = "none"     // tanpa enkripsi, hanya untuk server lokal seperti MailHog
+)
+
+// ParseTLSMode reads an SMTP_TLS value; kosong berarti starttls.
+func ParseTLSMode(v string) (TLSMode, error) {
+	switch m := TLSMode(strings.ToLower(strings.TrimSpace(v))); m {
+	case "":
+		return TLSStartTLS, nil
+	case TLSStartTLS, TLSImplicit, TLSNone:
+		return m, nil
+	}
+	return "", fmt.Errorf("SMTP_TLS must be starttls, implicit or none, got %q", v)
+}
+
+// SMTP sends mail over SMTP with STARTTLS/implicit TLS and PLAIN auth.
+type SMTP struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     mail.Address
+	TLS      TLSMode
+}
+
+func (s *SMTP) Name() string { return "smtp" }
+
+func (s *SMTP) Send(ctx context.Context, m Message) error {
+	body, err := s.build(m)
+	if err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	var conn net.Conn
+	if s.TLS == TLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.Host}}).DialContext(ctx, "tcp", s.Addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", s.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if s.TLS == TLSStartTLS {
+		// tidak turun ke koneksi polos: kredensial dan isi email tetap terenkripsi
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp: %s does not offer STARTTLS (set SMTP_TLS=none to send unencrypted)", s.Host)
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(m.To.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// build renders m as a multipart/alternative (text + HTML) message.
+func (s *SMTP) build(m Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(s.From.Address, "@"); at >= 0 {
+		domain = s.From.Address[at+1:]
+	}
+	headers := []string{
+		"From: " + s.From.String(),
+		"To: " + m.To.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%s@%s>", hex.EncodeToString(id), domain),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	var out bytes.Buffer
+	out.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct{ ctype, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.ctype},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
@@ -0,0 +1,216 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTLSMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TLSMode
+		wantErr bool
+	}{
+		{"", TLSStartTLS, false},
+		{"starttls", TLSStartTLS, false},
+		{" Implicit ", TLSImplicit, false},
+		{"none", TLSNone, false},
+		{"true", "", true},
+		{"ssl", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTLSMode(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTLSMode(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// fakeSMTP answers like a server without STARTTLS and records the commands.
+func fakeSMTP(t *testing.T) (addr string, commands <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	done := make(chan []string, 1)
+	go func() {
+		var seen []string
+		defer func() { done <- seen }()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		r := bufio.NewReader(conn)
+		io.WriteString(conn, "220 fake ESMTP\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.Fields(line + " x")[0])
+			seen = append(seen, cmd)
+			switch cmd {
+			case "EHLO":
+				io.WriteString(conn, "250-fake\r\n250 AUTH PLAIN\r\n")
+			case "DATA":
+				io.WriteString(conn, "354 go ahead\r\n")
+				for line != ".\r\n" {
+					if line, err = r.ReadString('\n'); err != nil {
+						return
+					}
+				}
+				io.WriteString(conn, "250 queued\r\n")
+			case "QUIT":
+				io.WriteString(conn, "221 bye\r\n")
+				return
+			default:
+				io.WriteString(conn, "250 ok\r\n")
+			}
+		}
+	}()
+	return ln.Addr().String(), done
+}
+
+func TestSendRequiresSTARTTLS(t *testing.T) {
+	addr, commands := fakeSMTP(t)
+	s := &SMTP{Addr: addr, Host: "127.0.0.1", From: mail.Address{Address: "no-reply@ekrp.test"}, TLS: TLSStartTLS}
+	err := s.Send(context.Background(), Message{To: mail.Address{Address: "dosen@ekrp.test"}, Subject: "x", Text: "x"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("err = %v, want a STARTTLS error", err)
+	}
+	for _, cmd := range <-commands {
+		if cmd == "MAIL" || cmd == "AUTH" {
+			t.Fatalf("%s was sent without TLS", cmd)
+		}
+	}
+}
+
+func TestSendWithoutTLS(t *testing.T) {
+	addr, commands := fakeSMTP(t)
+	s := &SMTP{Addr: addr, Host: "127.0.0.1", From: mail.Address{Address: "no-reply@ekrp.test"}, TLS: TLSNone}
+	if err := s.Send(context.Background(), Message{To: mail.Address{Address: "dosen@ekrp.test"}, Subject: "x", Text: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(<-commands, " ")
+	if got != "EHLO MAIL RCPT DATA QUIT" {
+		t.Fatalf("commands = %s", got)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	s := &SMTP{From: mail.Address{Name: "Sistem Prestasi", Address: "no-reply@ekrp.test"}}
+	longLine := strings.Repeat("panjang ", 20)
+	tests := []struct {
+		name      string
+		msg       Message
+		wantParts []string // content type tiap part, berurutan
+	}{
+		{"text and html", Message{
+			To:      mail.Address{Name: "Dosen Wali", Address: "dosen@ekrp.test"},
+			Subject: "Prestasi menunggu verifikasi: Juara 1 – Gemastik",
+			Text:    "Halo Bu Siti,\nNilai = 100%\n" + longLine + "\n",
+			HTML:    "<p>Halo <b>Bu Siti</b>, é</p>",
+		}, []string{"text/plain; charset=utf-8", "text/html; charset=utf-8"}},
+		{"text only", Message{
+			To:      mail.Address{Address: "mhs@ekrp.test"},
+			Subject: "Plain subject",
+			Text:    "Halo",
+		}, []string{"text/plain; charset=utf-8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := s.build(tt.msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			head, _, _ := bytes.Cut(raw, []byte("\r\n\r\n"))
+			for _, line := range strings.Split(string(head), "\r\n") {
+				for _, r := range line {
+					if r > 127 {
+						t.Fatalf("non-ASCII header line %q", line)
+					}
+				}
+			}
+
+			m, err := mail.ReadMessage(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dec := new(mime.WordDecoder)
+			if subject, err := dec.DecodeHeader(m.Header.Get("Subject")); err != nil || subject != tt.msg.Subject {
+				t.Fatalf("Subject = %q (%v), want %q", subject, err, tt.msg.Subject)
+			}
+			if from, err := m.Header.AddressList("From"); err != nil || from[0].String() != s.From.String() {
+				t.Fatalf("From = %v (%v)", from, err)
+			}
+			if to, err := m.Header.AddressList("To"); err != nil || to[0].Address != tt.msg.To.Address {
+				t.Fatalf("To = %v (%v)", to, err)
+			}
+			if m.Header.Get("MIME-Version") != "1.0" {
+				t.Fatal("missing MIME-Version")
+			}
+			if id := m.Header.Get("Message-ID"); !strings.HasSuffix(id, "@ekrp.test>") {
+				t.Fatalf("Message-ID = %q", id)
+			}
+			if _, err := mail.ParseDate(m.Header.Get("Date")); err != nil {
+				t.Fatalf("Date: %v", err)
+			}
+
+			mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+			if err != nil || mediaType != "multipart/alternative" {
+				t.Fatalf("Content-Type = %q (%v)", m.Header.Get("Content-Type"), err)
+			}
+			mr := multipart.NewReader(m.Body, params["boundary"])
+			bodies := map[string]string{"text/plain; charset=utf-8": tt.msg.Text, "text/html; charset=utf-8": tt.msg.HTML}
+			var parts []string
+			for {
+				p, err := mr.NextRawPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				ctype := p.Header.Get("Content-Type")
+				parts = append(parts, ctype)
+				if p.Header.Get("Content-Transfer-Encoding") != "quoted-printable" {
+					t.Fatalf("%s: not quoted-printable", ctype)
+				}
+				encoded, err := io.ReadAll(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, line := range strings.Split(string(encoded), "\r\n") {
+					if len(line) > 76 {
+						t.Fatalf("%s: encoded line longer than 76: %q", ctype, line)
+					}
+				}
+				// NextRawPart tidak men-decode; decode sendiri lalu bandingkan
+				decoded, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(encoded)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := strings.ReplaceAll(string(decoded), "\r\n", "\n"); got != bodies[ctype] {
+					t.Fatalf("%s body = %q, want %q", ctype, got, bodies[ctype])
+				}
+			}
+			if strings.Join(parts, ",") != strings.Join(tt.wantParts, ",") {
+				t.Fatalf("parts = %v, want %v", parts, tt.wantParts)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Bahasa template email; DefaultLanguage dipakai bila bahasa user tidak ada.
+const DefaultLanguage = "id"
+
+var Languages = []string{"id", "en"}
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*/*.html"))
+)
+
+// TemplateData is what the email templates can use.
+type TemplateData struct {
+	RecipientName    string
+	StudentName      string
+	ActorName        string
+	AchievementTitle string
+	Note             string // catatan penolakan
+	URL              string
+	Institution      string
+}
+
+// templateName: tiap file mendefinisikan "<lang>/<event>/subject" dan
+// "<lang>/<event>/text" (.txt) atau "<lang>/<event>/html" (.html).
+func templateName(lang, event, part string) string {
+	return lang + "/" + event + "/" + part
+}
+
+// Render renders the email of event in lang (fallback DefaultLanguage).
+func Render(event, lang string, data TemplateData) (subject, text, html string, err error) {
+	if textTemplates.Lookup(templateName(lang, event, "text")) == nil {
+		lang = DefaultLanguage
+	}
+	var b bytes.Buffer
+	if err = textTemplates.ExecuteTemplate(&b, templateName(lang, event, "subject"), data); err != nil {
+		return "", "", "", fmt.Errorf("email template %s/%s: %w", lang, event, err)
+	}
+	subject = strings.TrimSpace(b.String())
+	b.Reset()
+	if err = textTemplates.ExecuteTemplate(&b, templateName(lang, event, "text"), data); err != nil {
+		return "", "", "", fmt.Errorf("email template %s/%s: %w", lang, event, err)
+	}
+	text = strings.TrimSpace(b.String()) + "\n"
+	b.Reset()
+	if err = htmlTemplates.ExecuteTemplate(&b, templateName(lang, event, "html"), data); err != nil {
+		return "", "", "", fmt.Errorf("email template %s/%s: %w", lang, event, err)
+	}
+	return subject, text, b.String(), nil
+}
@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+var testData = TemplateData{
+	RecipientName:    "Bu Siti",
+	StudentName:      "Budi",
+	ActorName:        "Pak Andi",
+	AchievementTitle: "Juara 1 <Gemastik> & Hackathon",
+	Note:             "Lampiran sertifikat kurang jelas",
+	URL:              "https://ekrp.example/achievements/123",
+	Institution:      "Universitas Contoh",
+}
+
+func TestRender(t *testing.T) {
+	events := []string{"achievement.submitted", "achievement.verified", "achievement.rejected"}
+	subjects := map[string]string{
+		"id/achievement.rejected": "Prestasi ditolak: Juara 1 <Gemastik> & Hackathon",
+		"en/achievement.rejected": "Achievement rejected: Juara 1 <Gemastik> & Hackathon",
+	}
+	for _, lang := range Languages {
+		for _, event := range events {
+			t.Run(lang+"/"+event, func(t *testing.T) {
+				subject, text, html, err := Render(event, lang, testData)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want, ok := subjects[lang+"/"+event]; ok && subject != want {
+					t.Fatalf("subject = %q, want %q", subject, want)
+				}
+				if subject == "" || strings.Contains(subject, "\n") {
+					t.Fatalf("subject = %q", subject)
+				}
+				if !strings.Contains(text, testData.AchievementTitle) || !strings.Contains(text, testData.URL) {
+					t.Fatalf("text lacks title or URL:\n%s", text)
+				}
+				if !strings.HasSuffix(text, "\n") || strings.HasPrefix(text, "\n") {
+					t.Fatalf("text is not trimmed: %q", text)
+				}
+				// judul di-escape di HTML, tidak di teks
+				if !strings.Contains(html, "Juara 1 &lt;Gemastik&gt; &amp; Hackathon") || strings.Contains(html, "<Gemastik>") {
+					t.Fatalf("html does not escape the title:\n%s", html)
+				}
+				if !strings.Contains(html, `lang="`+lang+`"`) {
+					t.Fatalf("html is not in %s", lang)
+				}
+			})
+		}
+	}
+}
+
+func TestRenderLanguageFallback(t *testing.T) {
+	want, wantText, wantHTML, err := Render("achievement.verified", DefaultLanguage, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lang := range []string{"", "fr", "EN-us", "../id"} {
+		subject, text, html, err := Render("achievement.verified", lang, testData)
+		if err != nil {
+			t.Fatalf("%q: %v", lang, err)
+		}
+		if subject != want || text != wantText || html != wantHTML {
+			t.Fatalf("%q did not fall back to %s: %q", lang, DefaultLanguage, subject)
+		}
+	}
+}
+
+func TestRenderUnknownEvent(t *testing.T) {
+	if _, _, _, err := Render("achievement.commented", "en", testData); err == nil {
+		t.Fatal("event without a template should fail")
+	}
+}
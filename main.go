@@ -5,9 +5,14 @@ import (
     "log"
     "os"
 
+    "github.com/Lutfania/ekrp/app/models"
+    "github.com/Lutfania/ekrp/app/repository"
+    "github.com/Lutfania/ekrp/app/service"
     "github.com/Lutfania/ekrp/config"
     "github.com/Lutfania/ekrp/database"
+    "github.com/Lutfania/ekrp/middleware"
     "github.com/Lutfania/ekrp/routes"
+    "github.com/Lutfania/ekrp/storage"
 )
 
 func main() {
@@ -22,14 +27,38 @@ func main() {
         log.Fatal("❌ Failed to connect PostgreSQL:", err)
     }
 
+    // pastikan tabel-tabel tambahan sudah ada
+    if err := database.MigratePostgres(); err != nil {
+        log.Fatal("❌ Failed to migrate PostgreSQL:", err)
+    }
+
     // connect MongoDB (WAJIB!)
     if err := database.InitMongo(); err != nil {
         log.Fatal("❌ Failed to connect MongoDB:", err)
     }
 
+    // index Mongo (termasuk text index untuk pencarian)
+    if err := database.EnsureIndexes(models.SearchableExtraFields); err != nil {
+        log.Fatal("❌ Failed to create MongoDB indexes:", err)
+    }
+
+    // backend penyimpanan lampiran (STORAGE_BACKEND=local|s3)
+    if err := storage.Init(); err != nil {
+        log.Fatal("❌ Failed to init storage:", err)
+    }
+
+    // pembuat preview lampiran di background
+    previewWorker := service.NewPreviewWorker(repository.NewMongoAchievementRepository(), storage.Backend, 256)
+    previewWorker.Start(2)
+
     app := config.NewApp()
 
-    routes.RegisterRoutes(app)
+    routes.RegisterRoutes(app, previewWorker)
+
+    // semua permission yang dipakai route harus ada di tabel permissions
+    if err := middleware.ValidatePermissions(repository.NewPermissionRepository()); err != nil {
+        log.Fatal("❌ Invalid route permissions:", err)
+    }
 
     port := os.Getenv("PORT")
     if port == "" {
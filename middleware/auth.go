@@ -1,12 +1,16 @@
 package middleware
 
 import (
-	"github.com/Lutfania/ekrp/utils"
 	"strings"
 
+	"github.com/Lutfania/ekrp/app/repository"
+	"github.com/Lutfania/ekrp/utils"
+
 	"github.com/gofiber/fiber/v2"
 )
 
+var revocations = repository.NewTokenRevocationRepository()
+
 func JWTAuth(c *fiber.Ctx) error {
 	auth := c.Get("Authorization")
 	if auth == "" {
@@ -23,9 +27,18 @@ func JWTAuth(c *fiber.Ctx) error {
 		return c.Status(401).JSON(fiber.Map{"error": "invalid token"})
 	}
 
+	revoked, err := revocations.IsRevoked(claims.ID, claims.UserID, claims.TokenVersion)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "cannot check token revocation"})
+	}
+	if revoked {
+		return c.Status(401).JSON(fiber.Map{"error": "token revoked"})
+	}
+
 	c.Locals("claims", claims)
 	c.Locals("user_id", claims.UserID)
 	c.Locals("role_id", claims.RoleID)
+	c.Locals("token_version", claims.TokenVersion)
 
 	return c.Next()
 }
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects request bodies larger than limit(c) with 413. App
+// berjalan dengan StreamRequestBody, jadi body besar belum dibaca saat
+// middleware ini jalan; body chunked dibaca paling banyak limit+1 byte.
+func BodyLimit(limit func(c *fiber.Ctx) int64) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		max := limit(c)
+		req := c.Request()
+		length := req.Header.ContentLength()
+		if length > 0 && int64(length) > max {
+			return tooLarge(c, max)
+		}
+		if length == -1 && req.IsBodyStream() { // Transfer-Encoding: chunked
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), max+1))
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "cannot read request body"})
+			}
+			if int64(len(body)) > max {
+				return tooLarge(c, max)
+			}
+			req.SetBody(body)
+		}
+		return c.Next()
+	}
+}
+
+func tooLarge(c *fiber.Ctx, max int64) error {
+	// sisa body tidak dibaca, koneksi tidak bisa dipakai ulang
+	c.Context().SetConnectionClose()
+	return c.Status(413).JSON(fiber.Map{"error": "request body too large", "limit": max})
+}
@@ -1,40 +1,77 @@
 package middleware
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Lutfania/ekrp/utils"
+
 	"github.com/gofiber/fiber/v2"
 )
 
+// declaredPermissions mencatat semua permission yang dipakai oleh route,
+// supaya bisa dicek ke tabel permissions saat startup.
+var declaredPermissions = struct {
+	sync.Mutex
+	names map[string]struct{}
+}{names: map[string]struct{}{}}
+
+// PermissionLister is satisfied by repository.PermissionRepository.
+type PermissionLister interface {
+	ListPermissionNames() ([]string, error)
+}
+
+// RequirePermission must run after JWTAuth; it checks the permission list
+// carried in the token claims.
 func RequirePermission(permission string) fiber.Handler {
+	declaredPermissions.Lock()
+	declaredPermissions.names[permission] = struct{}{}
+	declaredPermissions.Unlock()
+
 	return func(c *fiber.Ctx) error {
 
 		// Ambil claims dari JWTAuth
-		claims := c.Locals("claims")
-		if claims == nil {
+		claims, ok := c.Locals("claims").(*utils.Claims)
+		if !ok || claims == nil {
 			return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
 		}
 
-		mapClaims := claims.(map[string]interface{})
-
-		// Ambil permissions
-		rawPerms := mapClaims["permissions"]
-		if rawPerms == nil {
-			return c.Status(403).JSON(fiber.Map{"error": "No permissions in token"})
+		for _, p := range claims.Permissions {
+			if p == permission {
+				return c.Next()
+			}
 		}
 
-		perms := rawPerms.([]interface{})
+		return c.Status(403).JSON(fiber.Map{"error": "Forbidden: insufficient permissions", "required": permission})
+	}
+}
 
-		has := false
-		for _, p := range perms {
-			if p.(string) == permission {
-				has = true
-				break
-			}
-		}
+// ValidatePermissions returns an error listing every permission declared by a
+// route that does not exist in the permissions table. Call it after routes
+// are registered.
+func ValidatePermissions(repo PermissionLister) error {
+	existing, err := repo.ListPermissionNames()
+	if err != nil {
+		return err
+	}
+	known := make(map[string]struct{}, len(existing))
+	for _, name := range existing {
+		known[name] = struct{}{}
+	}
 
-		if !has {
-			return c.Status(403).JSON(fiber.Map{"error": "Forbidden: insufficient permissions"})
+	declaredPermissions.Lock()
+	defer declaredPermissions.Unlock()
+	var missing []string
+	for name := range declaredPermissions.names {
+		if _, ok := known[name]; !ok {
+			missing = append(missing, name)
 		}
-
-		return c.Next()
 	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("permissions not found in table: %s", strings.Join(missing, ", "))
+	}
+	return nil
 }
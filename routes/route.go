@@ -1,71 +1,257 @@
 package routes
 
 import (
+	"expvar"
+	"log"
+	"regexp"
+	"time"
+
 	"github.com/Lutfania/ekrp/app/repository"
 	"github.com/Lutfania/ekrp/app/service"
+	"github.com/Lutfania/ekrp/config"
+	"github.com/Lutfania/ekrp/mailer"
 	"github.com/Lutfania/ekrp/middleware"
+	"github.com/Lutfania/ekrp/scanner"
+	"github.com/Lutfania/ekrp/storage"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 )
 
-func RegisterRoutes(app *fiber.App) {
+// RegisterRoutes mendaftarkan semua route. Setiap route (selain login/refresh,
+// endpoint milik user sendiri di /auth dan /notifications, download bertanda
+// tangan di /downloads dan endpoint verifikasi publik) wajib punya
+// RequirePermission; nama permission dicek ke tabel permissions lewat
+// middleware.ValidatePermissions. previewWorker dijalankan oleh main.
+func RegisterRoutes(app *fiber.App, previewWorker *service.PreviewWorker) {
+	app.Use(middleware.BodyLimit(bodyLimitFor))
 
 	// Repositories
 	userRepo := repository.NewUserRepository()
 	achRepo := repository.NewAchievementRepository()
-		mongoRepo := repository.NewMongoAchievementRepository()
+	mongoRepo := repository.NewMongoAchievementRepository()
 
 	studentRepo := repository.NewStudentRepository()
 	lecturerRepo := repository.NewLecturerRepository()
+	refreshRepo := repository.NewRefreshTokenRepository()
+	revocationRepo := repository.NewTokenRevocationRepository()
+	roleRepo := repository.NewRoleRepository()
+	permissionRepo := repository.NewPermissionRepository()
+	auditRepo := repository.NewAuditRepository()
+	historyRepo := repository.NewAchievementHistoryRepository()
+	pointRuleRepo := repository.NewPointRuleRepository()
+	leaderboardRepo := repository.NewLeaderboardRepository()
+	statsRepo := repository.NewStatsRepository()
+	uploadPolicyRepo := repository.NewUploadPolicyRepository()
+	resumableUploadRepo := repository.NewResumableUploadRepository()
+	verificationRepo := repository.NewVerificationRepository()
+	credentialKeyRepo := repository.NewCredentialKeyRepository()
+	commentRepo := repository.NewAchievementCommentRepository()
+	notificationRepo := repository.NewNotificationRepository()
+	emailOutboxRepo := repository.NewEmailOutboxRepository()
+
+	// email notifikasi; SMTP_HOST kosong = email nonaktif
+	mailSender, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatal("invalid SMTP config: ", err)
+	}
+
+	// private key kredensial disegel dengan CREDENTIAL_KEY_SECRET
+	if err := service.CheckCredentialKeySecret(); err != nil {
+		log.Fatal("invalid credential key config: ", err)
+	}
 
 	// Services
-	authService := service.NewAuthService(userRepo)
-	achService := service.NewAchievementService(achRepo, mongoRepo) // <-- perhatikan kedua repo
-	userService := service.NewUserService(userRepo)
-	studentService := service.NewStudentService(studentRepo)
-	lecturerService := service.NewLecturerService(lecturerRepo)
+	achPolicy := service.NewAchievementPolicy(userRepo, studentRepo, achRepo, revocationRepo)
+	authService := service.NewAuthService(userRepo, permissionRepo, refreshRepo, revocationRepo)
+	authService.StartRevocationJanitor(time.Hour)
+	pointsService := service.NewPointsService(pointRuleRepo, achRepo, mongoRepo)
+	uploadPolicyService := service.NewUploadPolicyService(uploadPolicyRepo, auditRepo, scanner.FromEnv())
+	if max := uploadPolicyService.MaxFileSize(); max < config.MaxResumableUploadBytes() {
+		log.Printf("upload size capped at %d bytes by the virus scanner stream limit (CLAMAV_MAX_STREAM_MB)", max)
+	}
+	achService := service.NewAchievementService(achRepo, mongoRepo, historyRepo, achPolicy, pointsService, storage.Backend, auditRepo, uploadPolicyService, previewWorker, commentRepo, mailSender) // <-- perhatikan kedua repo
+	resumableUploadService := service.NewResumableUploadService(resumableUploadRepo, achService)
+	resumableUploadService.StartJanitor(time.Hour)
+	verificationService := service.NewVerificationService(verificationRepo, mongoRepo, achPolicy, auditRepo)
+	credentialService := service.NewCredentialService(credentialKeyRepo, achService, studentRepo, userRepo, verificationRepo, auditRepo)
+	notificationService := service.NewNotificationService(notificationRepo)
+	emailOutboxService := service.NewEmailOutboxService(emailOutboxRepo, auditRepo)
+	if mailSender != nil {
+		service.NewEmailWorker(emailOutboxRepo, mailSender).Start(10 * time.Second)
+	}
+	userService := service.NewUserService(userRepo, revocationRepo, refreshRepo)
+	studentService := service.NewStudentService(studentRepo, achPolicy)
+	lecturerService := service.NewLecturerService(lecturerRepo, achPolicy)
+	roleService := service.NewRoleService(roleRepo, permissionRepo, auditRepo)
+	leaderboardService := service.NewLeaderboardService(leaderboardRepo, mongoRepo)
+	statsService := service.NewStatsService(statsRepo, achPolicy)
+
+	perm := middleware.RequirePermission
 
 	// AUTH
 	auth := app.Group("/api/v1/auth")
 	auth.Post("/login", authService.Login)
 	auth.Post("/refresh", authService.RefreshToken)
-	auth.Post("/logout", authService.Logout)
+	auth.Post("/logout", middleware.JWTAuth, authService.Logout)
+	auth.Post("/logout-all", middleware.JWTAuth, authService.LogoutAll)
 	auth.Get("/profile", middleware.JWTAuth, authService.Profile)
 
+	// NOTIFIKASI (milik user yang login sendiri, seperti /auth/profile)
+	notif := app.Group("/api/v1/notifications", middleware.JWTAuth)
+	notif.Get("/", notificationService.List)
+	notif.Get("/unread-count", notificationService.UnreadCount)
+	notif.Get("/preferences", notificationService.Preferences)
+	notif.Put("/preferences", notificationService.UpdatePreferences)
+	notif.Post("/read-all", notificationService.MarkAllRead)
+	notif.Post("/:id/read", notificationService.MarkRead)
+
 	// USERS
-	users := app.Group("/api/v1/users", middleware.JWTAuth)
+	users := app.Group("/api/v1/users", middleware.JWTAuth, perm("user:manage"))
 	users.Get("/", userService.FindAll)
 	users.Get("/:id", userService.FindById)
 	users.Post("/", userService.CreateUser)
 	users.Put("/:id", userService.UpdateUser)
 	users.Delete("/:id", userService.DeleteUser)
 	users.Put("/:id/role", userService.UpdateUserRole)
+	users.Put("/:id/status", userService.UpdateUserStatus)
+	users.Post("/:id/logout-all", userService.LogoutAll)
+
+	// ROLES & PERMISSIONS
+	roles := app.Group("/api/v1/roles", middleware.JWTAuth, perm("user:manage"))
+	roles.Get("/", roleService.FindAll)
+	roles.Get("/audit", roleService.AuditLog)
+	roles.Get("/:id", roleService.FindById)
+	roles.Post("/", roleService.Create)
+	roles.Put("/:id", roleService.Update)
+	roles.Delete("/:id", roleService.Delete)
+	roles.Get("/:id/users", roleService.FindUsers)
+	roles.Get("/:id/permissions", roleService.FindPermissions)
+	roles.Post("/:id/permissions", roleService.AttachPermission)
+	roles.Delete("/:id/permissions/:permissionId", roleService.DetachPermission)
+
+	app.Get("/api/v1/permissions", middleware.JWTAuth, perm("user:manage"), roleService.FindAllPermissions)
+
+	// POINT RULES
+	pointRules := app.Group("/api/v1/point-rules", middleware.JWTAuth, perm("user:manage"))
+	pointRules.Get("/", pointsService.FindAll)
+	pointRules.Get("/active", pointsService.FindActive)
+	pointRules.Post("/", pointsService.Create)
+	pointRules.Post("/recalculate", pointsService.Recalculate)
+	pointRules.Get("/:id", pointsService.FindById)
+
+	// LEADERBOARDS
+	leaderboardsGroup := app.Group("/api/v1/leaderboards", middleware.JWTAuth, perm("achievement:read"))
+	leaderboardsGroup.Get("/", leaderboardService.Ranking)
+	leaderboardsGroup.Get("/by/:dimension", leaderboardService.TopByGroup)
+
+	// STATS (isi dashboard mengikuti peran pemanggil)
+	stats := app.Group("/api/v1/stats", middleware.JWTAuth)
+	stats.Get("/", perm("achievement:read"), statsService.Dashboard)
+	stats.Get("/program-studies", perm("achievement:read"), statsService.ProgramStudies)
+	stats.Get("/advisors", perm("user:manage"), statsService.Advisors)
+
+	// UPLOAD POLICIES & METRICS
+	uploadPolicies := app.Group("/api/v1/upload-policies", middleware.JWTAuth, perm("user:manage"))
+	uploadPolicies.Get("/", uploadPolicyService.FindAll)
+	uploadPolicies.Put("/:type", uploadPolicyService.Update)
+
+	emailOutbox := app.Group("/api/v1/email-outbox", middleware.JWTAuth, perm("user:manage"))
+	emailOutbox.Get("/", emailOutboxService.List)
+	emailOutbox.Post("/:id/retry", emailOutboxService.Retry)
+
+	app.Get("/api/v1/metrics", middleware.JWTAuth, perm("user:manage"), adaptor.HTTPHandler(expvar.Handler()))
+
+	app.Get("/api/v1/achievement-types", middleware.JWTAuth, perm("achievement:read"), achService.AchievementTypes)
 
 	ach := app.Group("/api/v1/achievements", middleware.JWTAuth)
 
-	ach.Get("/", achService.List) // ?student_id=
-	ach.Get("/:id", achService.GetByID)
-	ach.Post("/", achService.Create)
-	ach.Put("/:id", achService.Update)
-	ach.Delete("/:id", achService.Delete)
-	ach.Post("/:id/submit", achService.Submit)
-	ach.Post("/:id/verify", achService.Verify)
-	ach.Post("/:id/reject", achService.Reject)
-	ach.Get("/:id/history", achService.History)
-	ach.Post("/:id/attachments", achService.UploadAttachment)
+	ach.Get("/", perm("achievement:read"), achService.List) // ?student_id=
+	ach.Get("/search", perm("achievement:read"), achService.Search)
+	ach.Get("/:id", perm("achievement:read"), achService.GetByID)
+	ach.Post("/", perm("achievement:create"), achService.Create)
+	ach.Put("/:id", perm("achievement:update"), achService.Update)
+	ach.Patch("/:id", perm("achievement:update"), achService.Patch)
+	ach.Delete("/:id", perm("achievement:delete"), achService.Delete)
+	ach.Post("/:id/submit", perm("achievement:update"), achService.Submit)
+	ach.Post("/:id/verify", perm("achievement:verify"), achService.Verify)
+	ach.Post("/:id/reject", perm("achievement:verify"), achService.Reject)
+	ach.Post("/:id/revise", perm("achievement:update"), achService.Revise)
+	ach.Post("/:id/archive", perm("achievement:update"), achService.Archive)
+	ach.Get("/:id/history", perm("achievement:read"), achService.History)
+	ach.Get("/:id/comments", perm("achievement:read"), achService.ListComments)
+	ach.Post("/:id/comments", perm("achievement:comment"), achService.AddComment)
+	ach.Get("/:id/versions", perm("achievement:read"), achService.Versions)
+	ach.Get("/:id/versions/diff", perm("achievement:read"), achService.DiffVersions)
+	ach.Get("/:id/versions/:version", perm("achievement:read"), achService.Version)
+	ach.Get("/:id/attachments", perm("achievement:read"), achService.ListAttachments)
+	ach.Post("/:id/attachments", perm("achievement:update"), achService.UploadAttachment)
+	ach.Get("/:id/attachments/:fileId", perm("achievement:read"), achService.DownloadAttachment)
+	ach.Get("/:id/attachments/:fileId/preview", perm("achievement:read"), achService.PreviewAttachment)
+	ach.Put("/:id/attachments/:fileId", perm("achievement:update"), achService.ReplaceAttachment)
+	ach.Delete("/:id/attachments/:fileId", perm("achievement:update"), achService.DeleteAttachment)
+
+	// upload resumable (tus 1.0) untuk file besar
+	ach.Options("/:id/uploads", perm("achievement:update"), resumableUploadService.Options)
+	ach.Post("/:id/uploads", perm("achievement:update"), resumableUploadService.Create)
+	ach.Head("/:id/uploads/:uploadId", perm("achievement:update"), resumableUploadService.Head)
+	ach.Patch("/:id/uploads/:uploadId", perm("achievement:update"), resumableUploadService.Patch)
+	ach.Delete("/:id/uploads/:uploadId", perm("achievement:update"), resumableUploadService.Terminate)
+
+	// kode verifikasi publik (QR)
+	ach.Get("/:id/verification", perm("achievement:read"), verificationService.List)
+	ach.Get("/:id/verification/qr", perm("achievement:read"), verificationService.QRCode)
+	ach.Post("/:id/verification", perm("achievement:verify"), verificationService.Reissue)
+	ach.Post("/:id/verification/revoke", perm("achievement:verify"), verificationService.Revoke)
+	ach.Get("/:id/credential", perm("achievement:read"), credentialService.Export)
+	ach.Get("/:id/openbadge", perm("achievement:read"), credentialService.OpenBadge)
+
+	// download lewat URL bertanda tangan (tanpa JWT; akses dicek dari uid + signature)
+	app.Get("/api/v1/downloads/:id/:fileId", achService.SignedDownload)
+	app.Get("/api/v1/downloads/:id/:fileId/preview", achService.SignedPreview)
+
+	// halaman verifikasi publik untuk pemindai QR (tanpa login, dibatasi per IP)
+	app.Get("/verify/:code", service.VerificationLimiter(), verificationService.Public)
+
+	// kredensial bertanda tangan: kunci publik dan verifikasi (publik)
+	app.Get("/.well-known/jwks.json", credentialService.JWKS)
+	app.Post("/api/v1/credentials/verify", service.VerificationLimiter(), credentialService.Verify)
+	app.Get("/api/v1/openbadges/issuer", credentialService.OpenBadgeIssuer)
+	app.Post("/api/v1/openbadges/verify", service.VerificationLimiter(), credentialService.VerifyOpenBadge)
+	keys := app.Group("/api/v1/credential-keys", middleware.JWTAuth, perm("user:manage"))
+	keys.Get("/", credentialService.ListKeys)
+	keys.Post("/rotate", credentialService.RotateKey)
 
 	// STUDENTS
 	students := app.Group("/api/v1/students", middleware.JWTAuth)
-	students.Get("/", studentService.FindAll)
-	students.Get("/:id", studentService.FindById)
-	students.Post("/", studentService.Create)
-	students.Put("/:id/advisor", studentService.UpdateAdvisor)
-	students.Get("/:id/achievements", studentService.FindAchievements)
+	students.Get("/", perm("user:manage"), studentService.FindAll)
+	students.Get("/:id", perm("user:manage"), studentService.FindById)
+	students.Post("/", perm("user:manage"), studentService.Create)
+	students.Put("/:id/advisor", perm("user:manage"), studentService.UpdateAdvisor)
+	students.Get("/:id/achievements", perm("achievement:read"), studentService.FindAchievements)
+	students.Get("/:id/points", perm("achievement:read"), studentService.Points)
+	students.Get("/:id/openbadges", perm("achievement:read"), credentialService.StudentOpenBadges)
+	students.Put("/:id/listing", perm("achievement:update"), studentService.UpdateListing)
 
 	// LECTURERS
 	lecturers := app.Group("/api/v1/lecturers", middleware.JWTAuth)
-	lecturers.Get("/", lecturerService.FindAll)
-	lecturers.Get("/:id", lecturerService.FindById)
-	lecturers.Post("/", lecturerService.Create)
-	lecturers.Get("/:id/advisees", lecturerService.FindAdvisees)
+	lecturers.Get("/", perm("user:manage"), lecturerService.FindAll)
+	lecturers.Get("/:id", perm("user:manage"), lecturerService.FindById)
+	lecturers.Post("/", perm("user:manage"), lecturerService.Create)
+	lecturers.Get("/:id/advisees", perm("achievement:verify"), lecturerService.FindAdvisees)
+}
+
+// uploadRoutes adalah route yang menerima isi file (multipart lampiran dan
+// PATCH tus); hanya route ini yang boleh mengirim body sampai MAX_UPLOAD_MB.
+var uploadRoutes = map[string]*regexp.Regexp{
+	fiber.MethodPost:  regexp.MustCompile(`(?i)^/api/v1/achievements/[^/]+/attachments/?$`),
+	fiber.MethodPut:   regexp.MustCompile(`(?i)^/api/v1/achievements/[^/]+/attachments/[^/]+/?$`),
+	fiber.MethodPatch: regexp.MustCompile(`(?i)^/api/v1/achievements/[^/]+/uploads/[^/]+/?$`),
+}
+
+func bodyLimitFor(c *fiber.Ctx) int64 {
+	if re, ok := uploadRoutes[c.Method()]; ok && re.MatchString(c.Path()) {
+		return config.MaxUploadBytes()
+	}
+	return config.MaxBodyBytes()
 }
@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const clamChunkSize = 64 * 1024
+
+// ClamAV scans through clamd's INSTREAM command: the file is sent as
+// length-prefixed chunks terminated by a zero-length chunk.
+type ClamAV struct {
+	Network string // unix | tcp
+	Address string
+	Timeout time.Duration
+	// MaxStream mengikuti StreamMaxLength clamd; stream yang lebih panjang
+	// dijawab "INSTREAM size limit exceeded".
+	MaxStream int64
+}
+
+func (c *ClamAV) Name() string { return "clamav" }
+
+func (c *ClamAV) MaxSize() int64 { return c.MaxStream }
+
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+	if c.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	buf := make([]byte, clamChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return nil, fmt.Errorf("clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return nil, fmt.Errorf("clamd: %w", err)
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return nil, rerr
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	return parseClamReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamReply handles "stream: OK", "stream: <name> FOUND" and "... ERROR".
+func parseClamReply(reply string) (*Result, error) {
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return &Result{Clean: true}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return &Result{Clean: false, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrNotFound is returned by Get when the key does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Storage menyimpan isi file lampiran. Key memakai "/" sebagai pemisah,
+// mis. "achievements/<id>/<file_id>.pdf".
+type Storage interface {
+	// Name identifies the backend ("local", "s3"); disimpan di metadata file.
+	Name() string
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Backend is the storage selected by Init.
+var Backend Storage
+
+// Init memilih backend dari STORAGE_BACKEND (local | s3, default local).
+//
+//	local: STORAGE_LOCAL_DIR (default ./uploads)
+//	s3:    S3_ENDPOINT, S3_ACCESS_KEY, S3_SECRET_KEY, S3_BUCKET, S3_REGION, S3_USE_SSL
+func Init() error {
+	switch backend := strings.ToLower(os.Getenv("STORAGE_BACKEND")); backend {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		s, err := NewLocal(dir)
+		if err != nil {
+			return err
+		}
+		Backend = s
+	case "s3":
+		s, err := NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+		})
+		if err != nil {
+			return err
+		}
+		Backend = s
+	default:
+		return fmt.Errorf("unknown STORAGE_BACKEND %q", backend)
+	}
+	return nil
+}
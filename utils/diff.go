@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"reflect"
+	"sort"
+)
+
+// FieldChange is one difference between two documents; Path uses dots for
+// nested objects (e.g. "extra.rank").
+type FieldChange struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"` // added | removed | changed
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// DiffMaps returns the field-level changes from a to b, recursing into nested
+// objects. Arrays are compared as a whole.
+func DiffMaps(a, b map[string]interface{}) []FieldChange {
+	changes := []FieldChange{}
+	diffInto(&changes, "", a, b)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffInto(out *[]FieldChange, prefix string, a, b map[string]interface{}) {
+	for k, av := range a {
+		path := joinPath(prefix, k)
+		bv, ok := b[k]
+		if !ok {
+			*out = append(*out, FieldChange{Path: path, Op: "removed", Old: av})
+			continue
+		}
+		am, aIsMap := NormalizeBSON(av).(map[string]interface{})
+		bm, bIsMap := NormalizeBSON(bv).(map[string]interface{})
+		if aIsMap && bIsMap {
+			diffInto(out, path, am, bm)
+			continue
+		}
+		if !reflect.DeepEqual(NormalizeBSON(av), NormalizeBSON(bv)) {
+			*out = append(*out, FieldChange{Path: path, Op: "changed", Old: av, New: bv})
+		}
+	}
+	for k, bv := range b {
+		if _, ok := a[k]; !ok {
+			*out = append(*out, FieldChange{Path: joinPath(prefix, k), Op: "added", New: bv})
+		}
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
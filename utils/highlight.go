@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// SearchTerms extracts the words and "quoted phrases" of a text query,
+// skipping negated terms (-word) which never appear in a match.
+func SearchTerms(q string) []string {
+	var terms []string
+	for i, part := range strings.Split(q, `"`) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if i%2 == 1 {
+			terms = append(terms, part) // di dalam tanda kutip: frasa utuh
+			continue
+		}
+		for _, w := range strings.Fields(part) {
+			if !strings.HasPrefix(w, "-") {
+				terms = append(terms, w)
+			}
+		}
+	}
+	return terms
+}
+
+// Highlight wraps the occurrences of terms in text with <mark></mark>; the rest
+// of the text is HTML-escaped. With width > 0 long text is cut to a snippet of
+// about width bytes around the first match. Returns "" when nothing matches.
+func Highlight(text string, terms []string, width int) string {
+	if len(terms) == 0 || text == "" {
+		return ""
+	}
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	// awalan kata juga ditandai: "gemas" -> "Gemastik". \b dan \w di RE2 hanya
+	// ASCII, jadi batas kata ditulis dengan kelas Unicode (huruf seperti é ikut).
+	re := regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}\p{M}_])((?:` + strings.Join(quoted, "|") + `)[\p{L}\p{N}\p{M}_]*)`)
+	var locs [][]int
+	for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+		locs = append(locs, m[2:4])
+	}
+	if len(locs) == 0 {
+		return ""
+	}
+
+	start, end := 0, len(text)
+	if width > 0 && len(text) > width {
+		start = locs[0][0] - width/3
+		if start < 0 {
+			start = 0
+		}
+		end = start + width
+		if end > len(text) {
+			end = len(text)
+		}
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, l := range locs {
+		if l[0] < pos || l[1] > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:l[0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[l[0]:l[1]]) + "</mark>")
+		pos = l[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{"prefix", "Juara Gemastik 2025", []string{"gemas"}, "Juara <mark>Gemastik</mark> 2025"},
+		{"non-ascii word", "Lomba karya ilmiah énergi", []string{"énergi"}, "Lomba karya ilmiah <mark>énergi</mark>"},
+		{"non-ascii suffix", "Festival Budaya Kréatif", []string{"kr"}, "Festival Budaya <mark>Kréatif</mark>"},
+		{"not mid-word", "kegemasan", []string{"gemas"}, ""},
+		{"not after non-ascii letter", "égemas", []string{"gemas"}, ""},
+		{"adjacent", "a a", []string{"a"}, "<mark>a</mark> <mark>a</mark>"},
+		{"escapes html", "<b>Gemastik</b>", []string{"gemastik"}, "&lt;b&gt;<mark>Gemastik</mark>&lt;/b&gt;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, tt.terms, 0); got != tt.want {
+				t.Fatalf("Highlight(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
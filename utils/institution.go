@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// PublicBaseURL reads PUBLIC_BASE_URL (default http://localhost:<PORT>), the
+// address printed in QR codes and credentials.
+func PublicBaseURL() string {
+	if u := os.Getenv("PUBLIC_BASE_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	return "http://localhost:" + port
+}
+
+// InstitutionName reads INSTITUTION_NAME, penerbit kredensial prestasi.
+func InstitutionName() string {
+	if n := os.Getenv("INSTITUTION_NAME"); n != "" {
+		return n
+	}
+	return "Universitas"
+}
@@ -7,12 +7,16 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
 	UserID      string   `json:"user_id"`
 	RoleID      string   `json:"role_id"`
 	Permissions []string `json:"permissions"`
+	// TokenVersion: token ditolak setelah versi token user dinaikkan (logout-all,
+	// ganti role, nonaktif), lihat TokenRevocationRepository.
+	TokenVersion int `json:"tv"`
 	jwt.RegisteredClaims
 }
 
@@ -24,10 +28,11 @@ func jwtSecret() ([]byte, error) {
 	return []byte(secret), nil
 }
 
+// access token sengaja berumur pendek; sesi diperpanjang lewat refresh token
 func jwtExpiry() (time.Duration, error) {
 	s := os.Getenv("JWT_EXPIRE_MIN")
 	if s == "" {
-		return time.Hour * 24, nil
+		return time.Minute * 15, nil
 	}
 	mins, err := strconv.Atoi(s)
 	if err != nil {
@@ -36,7 +41,7 @@ func jwtExpiry() (time.Duration, error) {
 	return time.Duration(mins) * time.Minute, nil
 }
 
-func GenerateTokenWithPermissions(userID, roleID string, permissions []string) (string, error) {
+func GenerateTokenWithPermissions(userID, roleID string, tokenVersion int, permissions []string) (string, error) {
 	secret, err := jwtSecret()
 	if err != nil {
 		return "", err
@@ -48,10 +53,13 @@ func GenerateTokenWithPermissions(userID, roleID string, permissions []string) (
 	}
 
 	claims := Claims{
-		UserID:      userID,
-		RoleID:      roleID,
-		Permissions: permissions,
+		UserID:       userID,
+		RoleID:       roleID,
+		Permissions:  permissions,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
+			// jti dipakai untuk revocation (logout)
+			ID:        uuid.NewString(),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp)),
 			Issuer:    "ekrp",
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MergePatch applies an RFC 7386 JSON Merge Patch to target and returns the
+// result; target is not modified. A nil value in patch removes the key.
+func MergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(target))
+	for k, v := range target {
+		out[k] = v
+	}
+	for k, pv := range patch {
+		if pv == nil {
+			delete(out, k)
+			continue
+		}
+		if pm, ok := pv.(map[string]interface{}); ok {
+			tm, _ := NormalizeBSON(out[k]).(map[string]interface{})
+			out[k] = MergePatch(tm, pm)
+			continue
+		}
+		out[k] = pv
+	}
+	return out
+}
+
+// NormalizeBSON converts values decoded by the mongo driver into plain Go
+// maps/slices (primitive.D -> map, primitive.A -> slice) so they can be
+// compared, merged and rendered as normal JSON objects.
+func NormalizeBSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case primitive.D:
+		m := make(map[string]interface{}, len(t))
+		for _, e := range t {
+			m[e.Key] = NormalizeBSON(e.Value)
+		}
+		return m
+	case primitive.M:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = NormalizeBSON(e)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = NormalizeBSON(e)
+		}
+		return m
+	case primitive.A:
+		s := make([]interface{}, len(t))
+		for i, e := range t {
+			s[i] = NormalizeBSON(e)
+		}
+		return s
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, e := range t {
+			s[i] = NormalizeBSON(e)
+		}
+		return s
+	}
+	return v
+}
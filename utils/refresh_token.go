@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GenerateRefreshToken returns a random opaque token for the client and the
+// hash that is stored in the database. The raw value is never persisted.
+func GenerateRefreshToken() (raw string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	raw = base64.RawURLEncoding.EncodeToString(b)
+	return raw, HashRefreshToken(raw), nil
+}
+
+// HashRefreshToken hashes a raw refresh token for lookup.
+func HashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// RefreshTokenExpiry reads REFRESH_EXPIRE_HOURS (default 30 days).
+func RefreshTokenExpiry() (time.Duration, error) {
+	s := os.Getenv("REFRESH_EXPIRE_HOURS")
+	if s == "" {
+		return time.Hour * 24 * 30, nil
+	}
+	hours, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSignatureInvalid = errors.New("invalid signature")
+	ErrSignatureExpired = errors.New("link expired")
+)
+
+// downloadSecret derives the download URL key from DOWNLOAD_URL_SECRET
+// (fallback JWT_SECRET) with HKDF, jadi kunci HMAC-nya tidak pernah sama
+// dengan kunci penandatangan JWT.
+func downloadSecret() []byte {
+	base := os.Getenv("DOWNLOAD_URL_SECRET")
+	if base == "" {
+		base = os.Getenv("JWT_SECRET")
+	}
+	key, err := hkdf.Key(sha256.New, []byte(base), nil, "ekrp download url v1", 32)
+	if err != nil {
+		panic(err) // hanya terjadi bila panjang kunci tidak valid
+	}
+	return key
+}
+
+// DownloadURLTTL reads DOWNLOAD_URL_TTL_MIN (default 15 minutes).
+func DownloadURLTTL() time.Duration {
+	if min, err := strconv.Atoi(os.Getenv("DOWNLOAD_URL_TTL_MIN")); err == nil && min > 0 {
+		return time.Duration(min) * time.Minute
+	}
+	return 15 * time.Minute
+}
+
+func downloadSignature(parts ...string) string {
+	mac := hmac.New(sha256.New, downloadSecret())
+	mac.Write([]byte(strings.Join(parts, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignDownload returns the query string (uid, tv, exp, sig) that lets userID
+// download fileID of achievementID until exp without a bearer token.
+// tokenVersion is the tv claim of the requesting token.
+func SignDownload(achievementID, fileID, userID string, tokenVersion int, exp time.Time) string {
+	v := strconv.Itoa(tokenVersion)
+	e := strconv.FormatInt(exp.Unix(), 10)
+	q := url.Values{}
+	q.Set("uid", userID)
+	q.Set("tv", v)
+	q.Set("exp", e)
+	q.Set("sig", downloadSignature(achievementID, fileID, userID, v, e))
+	return q.Encode()
+}
+
+// VerifyDownload checks a signature made by SignDownload and returns the
+// token version of the link (untuk dicek terhadap revocation).
+func VerifyDownload(achievementID, fileID, userID, tv, exp, sig string) (int, error) {
+	expected := downloadSignature(achievementID, fileID, userID, tv, exp)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return 0, ErrSignatureInvalid
+	}
+	version, err := strconv.Atoi(tv)
+	if err != nil {
+		return 0, ErrSignatureInvalid
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return 0, ErrSignatureInvalid
+	}
+	if time.Now().Unix() > unix {
+		return 0, ErrSignatureExpired
+	}
+	return version, nil
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// magic bytes dari format yang kita kenali
+var magicNumbers = []struct {
+	prefix      []byte
+	contentType string
+}{
+	{[]byte("%PDF-"), "application/pdf"},
+	{[]byte{0xFF, 0xD8, 0xFF}, "image/jpeg"},
+	{[]byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}, "image/png"},
+}
+
+// SniffContentType detects the type of a file from its first bytes (up to
+// 512). PDF, JPEG and PNG are matched on their magic numbers; anything else
+// falls back to http.DetectContentType.
+func SniffContentType(head []byte) string {
+	for _, m := range magicNumbers {
+		if bytes.HasPrefix(head, m.prefix) {
+			return m.contentType
+		}
+	}
+	return http.DetectContentType(head)
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+// ErrNoPreview means no preview can be made from the file in pure Go.
+var ErrNoPreview = errors.New("no preview available")
+
+// ErrImageTooLarge means the image has more pixels than maxPreviewPixels.
+var ErrImageTooLarge = errors.New("image too large for a preview")
+
+const (
+	// maxPreviewSource membatasi ukuran file yang dibaca untuk membuat preview.
+	maxPreviewSource = 64 << 20
+	// maxPreviewPixels membatasi dimensi gambar yang di-decode (~40 MP);
+	// file kecil bisa mengklaim dimensi sangat besar (decompression bomb).
+	maxPreviewPixels = 40_000_000
+)
+
+// Thumbnail returns a JPEG no larger than maxDim x maxDim for a JPEG/PNG
+// image. Untuk PDF yang dipakai adalah JPEG pertama yang tertanam di file
+// (hasil scan sertifikat biasanya begitu), bukan render halaman pertama.
+// Other files give ErrNoPreview; images above maxPreviewPixels give
+// ErrImageTooLarge before being decoded.
+func Thumbnail(r io.Reader, contentType string, maxDim int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPreviewSource))
+	if err != nil {
+		return nil, err
+	}
+	var src image.Image
+	switch contentType {
+	case "image/jpeg":
+		src, err = decodeBounded(data, jpeg.DecodeConfig, jpeg.Decode)
+	case "image/png":
+		src, err = decodeBounded(data, png.DecodeConfig, png.Decode)
+	case "application/pdf":
+		embedded := firstEmbeddedJPEG(data)
+		if embedded == nil {
+			return nil, ErrNoPreview
+		}
+		src, err = decodeBounded(embedded, jpeg.DecodeConfig, jpeg.Decode)
+	default:
+		return nil, ErrNoPreview
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil, ErrNoPreview
+	}
+	if w > maxDim || h > maxDim {
+		if w >= h {
+			w, h = maxDim, h*maxDim/w
+		} else {
+			w, h = w*maxDim/h, maxDim
+		}
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+	}
+	// latar putih supaya PNG transparan tetap terbaca sebagai JPEG
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// decodeBounded reads the image header first and only decodes images of
+// at most maxPreviewPixels.
+func decodeBounded(data []byte, config func(io.Reader) (image.Config, error), decode func(io.Reader) (image.Image, error)) (image.Image, error) {
+	cfg, err := config(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrNoPreview
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxPreviewPixels {
+		return nil, ErrImageTooLarge
+	}
+	return decode(bytes.NewReader(data))
+}
+
+// firstEmbeddedJPEG returns the data of the first DCTDecode (JPEG) stream in
+// a PDF, or nil. Tidak ada hubungannya dengan urutan halaman: objek PDF tidak
+// di-parse, cukup cari dictionary dengan filter DCTDecode lalu ambil isi
+// stream setelahnya.
+func firstEmbeddedJPEG(pdf []byte) []byte {
+	rest := pdf
+	for {
+		i := bytes.Index(rest, []byte("/DCTDecode"))
+		if i < 0 {
+			return nil
+		}
+		rest = rest[i:]
+		s := bytes.Index(rest, []byte("stream"))
+		if s < 0 {
+			return nil
+		}
+		body := rest[s+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		e := bytes.Index(body, []byte("endstream"))
+		if e < 0 {
+			return nil
+		}
+		data := bytes.TrimRight(body[:e], "\r\n")
+		if bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+			return data
+		}
+		rest = body[e:]
+	}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withJPEGSize rewrites the dimensions in the SOF0 header, tanpa mengubah
+// data gambarnya (seperti decompression bomb).
+func withJPEGSize(t *testing.T, data []byte, w, h uint16) []byte {
+	t.Helper()
+	out := append([]byte(nil), data...)
+	i := bytes.Index(out, []byte{0xFF, 0xC0})
+	if i < 0 {
+		t.Fatal("no SOF0 marker")
+	}
+	out[i+5], out[i+6] = byte(h>>8), byte(h)
+	out[i+7], out[i+8] = byte(w>>8), byte(w)
+	return out
+}
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	src.Set(0, 0, color.Black)
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, src); err != nil {
+		t.Fatal(err)
+	}
+	small := encodeJPEG(t, 40, 20)
+	bomb := withJPEGSize(t, small, 10000, 10000)
+	pdf := func(embedded []byte) []byte {
+		return append(append([]byte("%PDF-1.4\n1 0 obj << /Filter /DCTDecode /Length 1 >>\nstream\n"), embedded...), "\nendstream\nendobj\n"...)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		wantErr     error
+		wantW       int
+		wantH       int
+	}{
+		{"jpeg", small, "image/jpeg", nil, 10, 5},
+		{"png", pngData.Bytes(), "image/png", nil, 10, 5},
+		{"pdf embedded jpeg", pdf(small), "application/pdf", nil, 10, 5},
+		{"pdf without jpeg", []byte("%PDF-1.4\n"), "application/pdf", ErrNoPreview, 0, 0},
+		{"unsupported type", small, "application/zip", ErrNoPreview, 0, 0},
+		{"jpeg over pixel cap", bomb, "image/jpeg", ErrImageTooLarge, 0, 0},
+		{"pdf jpeg over pixel cap", pdf(bomb), "application/pdf", ErrImageTooLarge, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Thumbnail(bytes.NewReader(tt.data), tt.contentType, 10)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Fatalf("thumbnail is %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailPNGOverPixelCap(t *testing.T) {
+	// cukup signature + IHDR 8000x8000 (64 MP); DecodeConfig tidak membaca lebih jauh
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], 8000)
+	binary.BigEndian.PutUint32(ihdr[8:], 8000)
+	ihdr[12], ihdr[13] = 8, 0 // 8-bit grayscale
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(13))
+	buf.Write(ihdr)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+
+	if _, err := Thumbnail(&buf, "image/png", 10); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("err = %v, want ErrImageTooLarge", err)
+	}
+}
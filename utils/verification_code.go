@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// base32 tanpa padding, huruf kecil: aman di URL dan mudah diketik ulang
+var codeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateVerificationCode returns an unguessable code (160 bit random).
+func GenerateVerificationCode() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToLower(codeEncoding.EncodeToString(b)), nil
+}
+
+// VerificationURL is the public page of a verification code.
+func VerificationURL(code string) string {
+	return PublicBaseURL() + "/verify/" + code
+}
+
+// VerificationQR renders the verification URL of code as a size x size PNG.
+func VerificationQR(code string, size int) ([]byte, error) {
+	return qrcode.Encode(VerificationURL(code), qrcode.Medium, size)
+}